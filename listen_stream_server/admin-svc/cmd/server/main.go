@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 
@@ -21,7 +20,9 @@ import (
 	"listen-stream/admin-svc/internal/service"
 	sharedConfig "listen-stream/shared/pkg/config"
 	"listen-stream/shared/pkg/crypto"
+	"listen-stream/shared/pkg/metrics"
 	"listen-stream/shared/pkg/rdb"
+	"listen-stream/shared/pkg/tracing"
 )
 
 func main() {
@@ -35,10 +36,16 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Tracing: exporter chosen by OTEL_TRACES_EXPORTER (default none).
+	shutdownTracing, err := tracing.Setup(context.Background(), "admin-svc")
+	if err != nil {
+		logger.Fatal("init tracing", zap.Error(err))
+	}
+
 	// ── 2. Postgres ────────────────────────────────────────────────────────────
-	pool, err := pgxpool.New(context.Background(), mustEnv("DATABASE_URL"))
+	pool, err := tracing.NewPool(context.Background(), mustEnv("DATABASE_URL"))
 	if err != nil {
-		logger.Fatal("pgxpool.New", zap.Error(err))
+		logger.Fatal("connect postgres", zap.Error(err))
 	}
 	defer pool.Close()
 
@@ -69,7 +76,7 @@ func main() {
 
 	// ── 8. Gin router ──────────────────────────────────────────────────────────
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(metrics.Middleware(), tracing.Middleware("admin-svc"), gin.Recovery())
 	r.Use(accessLogger(logger))
 
 	// Health check (no auth)
@@ -102,6 +109,9 @@ func main() {
 	statsH := handler.NewStatsHandler(base)
 	statsH.Register(api.Group("/stats"))
 
+	cacheH := handler.NewCacheHandler(base)
+	cacheH.Register(api.Group("/cache"))
+
 	// ── 9. Start server with graceful shutdown ─────────────────────────────────
 	port := getEnv("PORT", "8004")
 	srv := &http.Server{Addr: ":" + port, Handler: r}
@@ -113,6 +123,15 @@ func main() {
 		}
 	}()
 
+	// Prometheus scrape endpoint on its own listener, kept off the public port.
+	metricsSrv := metrics.NewServer(getEnv("METRICS_ADDR", ":9004"))
+	go func() {
+		logger.Info("admin-svc metrics listening", zap.String("addr", metricsSrv.Addr))
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server error", zap.Error(err))
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -123,6 +142,8 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("server shutdown", zap.Error(err))
 	}
+	_ = metricsSrv.Shutdown(ctx)
+	_ = shutdownTracing(ctx)
 	logger.Info("admin-svc stopped")
 }
 
@@ -0,0 +1,286 @@
+// Package handler — cache_handler inspects and purges proxy-svc's response cache.
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	mw "listen-stream/admin-svc/internal/middleware"
+	"listen-stream/shared/pkg/rdb"
+)
+
+// maxStatsHours bounds GET /admin/cache/stats; older buckets have expired.
+const maxStatsHours = 48
+
+// CacheHandler serves admin endpoints for the proxy:* cache.
+type CacheHandler struct{ *Base }
+
+// NewCacheHandler creates a CacheHandler.
+func NewCacheHandler(b *Base) *CacheHandler { return &CacheHandler{b} }
+
+// Register mounts cache routes; all require RequireAdmin.
+func (h *CacheHandler) Register(rg *gin.RouterGroup) {
+	auth := mw.RequireAdmin(h.jwtSvc)
+	rg.GET("/paths", auth, h.listPaths)
+	rg.DELETE("", auth, h.purge)
+	rg.GET("/stats", auth, h.stats)
+}
+
+// cachedPath summarises the cache keys of one upstream path.
+type cachedPath struct {
+	Path      string `json:"path"`
+	Keys      int    `json:"keys"`       // live entries
+	StaleKeys int    `json:"stale_keys"` // stale backups (outlive live entries up to 2×)
+	MinTTL    int64  `json:"min_ttl"`    // seconds, over live entries
+	MaxTTL    int64  `json:"max_ttl"`
+}
+
+// listPaths lists cached upstream paths with key counts and TTL ranges.
+//
+//	GET /admin/cache/paths?prefix=/song
+func (h *CacheHandler) listPaths(c *gin.Context) {
+	ctx := c.Request.Context()
+	byPath := make(map[string]*cachedPath)
+	seen := make(map[string]struct{})
+
+	err := h.rdb.ScanEach(ctx, rdb.KeyProxyCachePattern(c.Query("prefix")), func(keys []string) error {
+		ttls, err := h.rdb.TTLs(ctx, keys)
+		if err != nil {
+			return err
+		}
+		for i, k := range keys {
+			if _, dup := seen[k]; dup {
+				continue
+			}
+			seen[k] = struct{}{}
+			path, kind, ok := parseProxyKey(k)
+			if !ok || ttls[i] < 0 {
+				continue
+			}
+			p := byPath[path]
+			if p == nil {
+				p = &cachedPath{Path: path, MinTTL: -1}
+				byPath[path] = p
+			}
+			if kind == "stale" {
+				p.StaleKeys++
+				continue
+			}
+			p.Keys++
+			ttl := int64(ttls[i].Seconds())
+			if p.MinTTL < 0 || ttl < p.MinTTL {
+				p.MinTTL = ttl
+			}
+			if ttl > p.MaxTTL {
+				p.MaxTTL = ttl
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		h.log.Error("scan proxy cache", zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	paths := make([]cachedPath, 0, len(byPath))
+	keys := 0
+	for _, p := range byPath {
+		if p.MinTTL < 0 {
+			p.MinTTL = 0 // only stale backups left
+		}
+		keys += p.Keys
+		paths = append(paths, *p)
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Path < paths[j].Path })
+	c.JSON(http.StatusOK, gin.H{"data": paths, "total": len(paths), "keys": keys})
+}
+
+// purge deletes cached responses, including their stale backups, either for
+// every upstream path starting with prefix or for one exact path + query.
+// query must be the upstream query string as proxy-svc forwards it
+// (format=normalized is never part of it).
+//
+//	DELETE /admin/cache?prefix=/lyric
+//	DELETE /admin/cache?path=/song/detail&query=id%3D123
+func (h *CacheHandler) purge(c *gin.Context) {
+	prefix, path := c.Query("prefix"), c.Query("path")
+	if (prefix == "") == (path == "") {
+		jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS", "exactly one of prefix or path is required")
+		return
+	}
+	if !strings.HasPrefix(prefix+path, "/") {
+		jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS", "prefix and path must start with /")
+		return
+	}
+	ctx := c.Request.Context()
+
+	var (
+		deleted int64
+		err     error
+		target  string
+		inv     = map[string]string{"origin": "admin-svc"}
+	)
+	if prefix != "" {
+		target = prefix + "*"
+		inv["prefix"] = prefix
+		deleted, err = h.rdb.ScanDel(ctx, rdb.KeyProxyCachePattern(prefix))
+	} else {
+		// Matches the live key plus its ":stale" sibling.
+		query := c.Query("query")
+		hash := rdb.ProxyQueryHash(path, query)
+		target = path + "?" + query
+		inv["key"] = rdb.KeyProxyCache(path, hash)
+		deleted, err = h.rdb.ScanDel(ctx, rdb.KeyProxyCachePattern(path+":"+hash))
+	}
+	if err != nil {
+		h.log.Error("purge proxy cache", zap.String("target", target), zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	// Make every proxy-svc replica drop its in-process copy too.
+	msg, _ := json.Marshal(inv)
+	if err := h.rdb.Publish(ctx, rdb.KeyProxyInvalidateChannel(), string(msg)); err != nil {
+		h.log.Warn("publish cache invalidation", zap.Error(err))
+	}
+
+	if claims := mw.GetAdminClaims(c); claims != nil {
+		go auditLog(context.Background(), h.q, claims.Subject, "CACHE_PURGED", ptrStr(target), nil, nil, c.ClientIP())
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
+// cacheCounts are X-Cache outcomes; Stale includes STALE-REVALIDATING.
+type cacheCounts struct {
+	Hit        int64   `json:"hit"`
+	Miss       int64   `json:"miss"`
+	Stale      int64   `json:"stale"`
+	Error      int64   `json:"error"`
+	Total      int64   `json:"total"`
+	HitRatio   float64 `json:"hit_ratio"`
+	MissRatio  float64 `json:"miss_ratio"`
+	StaleRatio float64 `json:"stale_ratio"`
+}
+
+func (cc *cacheCounts) add(status string, n int64) {
+	switch status {
+	case "HIT":
+		cc.Hit += n
+	case "MISS":
+		cc.Miss += n
+	case "STALE", "STALE-REVALIDATING":
+		cc.Stale += n
+	case "ERROR":
+		cc.Error += n
+	default:
+		return
+	}
+	cc.Total += n
+}
+
+func (cc *cacheCounts) ratios() {
+	if cc.Total == 0 {
+		return
+	}
+	t := float64(cc.Total)
+	cc.HitRatio = float64(cc.Hit) / t
+	cc.MissRatio = float64(cc.Miss) / t
+	cc.StaleRatio = float64(cc.Stale) / t
+}
+
+// stats returns cache hit / miss / stale ratios over the last hours (default
+// 24, max 48), overall and per upstream path, plus how many responses each
+// tier served (memory, redis, upstream), from the hourly counters proxy-svc
+// maintains in Redis.
+//
+//	GET /admin/cache/stats?hours=24
+func (h *CacheHandler) stats(c *gin.Context) {
+	hours := 24
+	if v := c.Query("hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxStatsHours {
+			jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS", "hours must be 1.."+strconv.Itoa(maxStatsHours))
+			return
+		}
+		hours = n
+	}
+	ctx := c.Request.Context()
+
+	var overall cacheCounts
+	byPath := make(map[string]*cacheCounts)
+	tiers := map[string]int64{"memory": 0, "redis": 0, "upstream": 0}
+	now := time.Now().UTC()
+	for i := 0; i < hours; i++ {
+		hour := now.Add(-time.Duration(i) * time.Hour).Format("2006010215")
+		tierFields, err := h.rdb.HGetAll(ctx, rdb.KeyProxyTierStats(hour))
+		if err != nil {
+			h.log.Error("read proxy tier stats", zap.String("hour", hour), zap.Error(err))
+			jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+		for tier, v := range tierFields {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				tiers[tier] += n
+			}
+		}
+		fields, err := h.rdb.HGetAll(ctx, rdb.KeyProxyStats(hour))
+		if err != nil {
+			h.log.Error("read proxy stats", zap.String("hour", hour), zap.Error(err))
+			jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+		for field, v := range fields {
+			path, status, ok := strings.Cut(field, "|")
+			n, err := strconv.ParseInt(v, 10, 64)
+			if !ok || err != nil {
+				continue
+			}
+			p := byPath[path]
+			if p == nil {
+				p = &cacheCounts{}
+				byPath[path] = p
+			}
+			p.add(status, n)
+			overall.add(status, n)
+		}
+	}
+
+	type pathCounts struct {
+		Path string `json:"path"`
+		cacheCounts
+	}
+	paths := make([]pathCounts, 0, len(byPath))
+	for path, cc := range byPath {
+		cc.ratios()
+		paths = append(paths, pathCounts{Path: path, cacheCounts: *cc})
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Total > paths[j].Total })
+	overall.ratios()
+	c.JSON(http.StatusOK, gin.H{"hours": hours, "overall": overall, "tiers": tiers, "data": paths})
+}
+
+// parseProxyKey splits "proxy:<path>:<hash>[:stale]" into the upstream
+// path and the key kind ("live" or "stale").
+func parseProxyKey(key string) (path, kind string, ok bool) {
+	rest, ok := strings.CutPrefix(key, "proxy:")
+	if !ok {
+		return "", "", false
+	}
+	kind = "live"
+	if r, found := strings.CutSuffix(rest, ":stale"); found {
+		rest, kind = r, "stale"
+	}
+	i := strings.LastIndexByte(rest, ':')
+	if i <= 0 {
+		return "", "", false
+	}
+	return rest[:i], kind, true
+}
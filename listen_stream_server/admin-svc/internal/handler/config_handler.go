@@ -9,7 +9,9 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -19,7 +21,10 @@ import (
 	mw "listen-stream/admin-svc/internal/middleware"
 	"listen-stream/admin-svc/internal/repo"
 	"listen-stream/admin-svc/internal/util"
+	"listen-stream/shared/pkg/config"
+	"listen-stream/shared/pkg/proxyttl"
 	"listen-stream/shared/pkg/rdb"
+	"listen-stream/shared/pkg/upstreams"
 )
 
 // ConfigHandler manages system configuration via admin API.
@@ -34,6 +39,10 @@ func (h *ConfigHandler) Register(rg *gin.RouterGroup) {
 	rg.GET("/api", auth, h.getAPIConfig)
 	rg.PUT("/api", auth, h.updateAPIConfig)
 	rg.POST("/api/test", auth, h.testAPIConnection)
+	rg.GET("/upstreams", auth, h.getUpstreams)
+	rg.PUT("/upstreams", auth, h.updateUpstreams)
+	rg.GET("/proxy-ttl", auth, h.getProxyTTL)
+	rg.PUT("/proxy-ttl", auth, h.updateProxyTTL)
 	rg.GET("/jwt", auth, h.getJWTConfig)
 	rg.PUT("/jwt", auth, mw.RequireRole("SUPER_ADMIN"), h.updateJWTConfig)
 	rg.GET("/sms", auth, h.getSMSConfig)
@@ -44,7 +53,29 @@ func (h *ConfigHandler) Register(rg *gin.RouterGroup) {
 
 // ── API config ────────────────────────────────────────────────────────────────
 
-var apiConfigKeys = []string{"API_BASE_URL", "API_FALLBACK_URL", "API_KEY"}
+// apiConfigKeys are the proxy-svc runtime settings read and written through
+// /admin/config/api, grouped by feature. UPSTREAM_ENDPOINTS and PROXY_TTLS
+// have their own validated routes.
+var apiConfigKeys = []string{
+	// Upstream API and login cookie
+	"API_BASE_URL", "API_FALLBACK_URL", "API_KEY",
+	"COOKIE", "COOKIE_POOL", "UPSTREAM_AUTH_EXPIRED_MESSAGES",
+	// Upstream health
+	"UPSTREAM_PROBE_INTERVAL", "UPSTREAM_BREAKER_THRESHOLD", "UPSTREAM_BREAKER_COOLDOWN",
+	// Proxy cache
+	"PROXY_SOFT_TTL_RATIO", "PROXY_MEMORY_CACHE_MB",
+	"CACHE_WARM_CRON", "CACHE_WARM_PATHS", "CACHE_WARM_LEAD",
+	// Rate limits and aggregate endpoints
+	"RATE_LIMITS", "BATCH_MAX_ITEMS", "SEARCH_ALL_LIMITS",
+	// Playback
+	"PLAYBACK_SOURCES", "PLAYBACK_QUALITY_POLICY", "PLAYBACK_URL_TTL", "PLAYBACK_NEGATIVE_TTL",
+	"JOOX_MATCH_THRESHOLD",
+	// Access log
+	"PROXY_LOG_RETENTION_DAYS",
+}
+
+// apiSecretKeys are masked in GET responses and redacted in the audit log.
+var apiSecretKeys = map[string]bool{"API_KEY": true, "COOKIE": true, "COOKIE_POOL": true}
 
 func (h *ConfigHandler) getAPIConfig(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -53,8 +84,10 @@ func (h *ConfigHandler) getAPIConfig(c *gin.Context) {
 		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", "config read failed")
 		return
 	}
-	if v, ok := vals["API_KEY"]; ok {
-		vals["API_KEY"] = util.MaskSecret(v)
+	for k, v := range vals {
+		if apiSecretKeys[k] {
+			vals[k] = util.MaskSecret(v)
+		}
 	}
 	c.JSON(http.StatusOK, vals)
 }
@@ -71,24 +104,207 @@ func (h *ConfigHandler) updateAPIConfig(c *gin.Context) {
 	if claims != nil {
 		updatedBy = claims.Username
 	}
-	allowed := map[string]bool{"API_BASE_URL": true, "API_FALLBACK_URL": true, "API_KEY": true}
 	for k, v := range req {
-		if !allowed[k] {
+		if !slices.Contains(apiConfigKeys, k) {
 			continue
 		}
 		// Trim whitespace from values to prevent common input errors
 		v = strings.TrimSpace(v)
+		before, _ := h.cfgSvc.Get(ctx, k)
 		if err := h.cfgSvc.Set(ctx, k, v, updatedBy); err != nil {
 			h.log.Error("update api config", zap.String("key", k), zap.Error(err))
 			jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update "+k)
 			return
 		}
+		after := v
+		if apiSecretKeys[k] {
+			before, after = "[secret]", "[secret]"
+		}
 		go auditLog(context.Background(), h.q, claims.Subject, "CONFIG_UPDATE",
-			ptrStr(k), ptrStr("[secret]"), ptrStr("[secret]"), c.ClientIP())
+			ptrStr(k), ptrStr(before), ptrStr(after), c.ClientIP())
+		// A new cookie resolves a rejected-cookie alert raised by proxy-svc.
+		if k == "COOKIE" || k == "COOKIE_POOL" {
+			_ = h.rdb.Del(ctx, rdb.KeyCookieAlert())
+		}
 	}
 	c.JSON(http.StatusOK, gin.H{"updated": len(req)})
 }
 
+// ── Upstream pool ─────────────────────────────────────────────────────────────
+
+// currentUpstreams returns the effective upstream pool with real API keys:
+// UPSTREAM_ENDPOINTS when set, else the pool proxy-svc derives from the
+// legacy keys (legacy == true).
+func (h *ConfigHandler) currentUpstreams(ctx context.Context) (eps []upstreams.Endpoint, legacy bool, err error) {
+	vals, err := h.cfgSvc.GetMany(ctx, []string{upstreams.ConfigKey, "API_BASE_URL", "API_FALLBACK_URL", "API_KEY"})
+	if err != nil {
+		return nil, false, err
+	}
+	eps, perr := upstreams.Parse(vals[upstreams.ConfigKey])
+	if perr != nil {
+		// Stored value is unusable; proxy-svc ignores it too.
+		h.log.Warn("invalid stored UPSTREAM_ENDPOINTS", zap.Error(perr))
+		eps = nil
+	}
+	if eps == nil {
+		return upstreams.Legacy(vals["API_BASE_URL"], vals["API_FALLBACK_URL"], vals["API_KEY"]), true, nil
+	}
+	return eps, false, nil
+}
+
+func maskEndpoints(eps []upstreams.Endpoint) []upstreams.Endpoint {
+	out := make([]upstreams.Endpoint, len(eps))
+	for i, ep := range eps {
+		if ep.APIKey != "" {
+			ep.APIKey = util.MaskSecret(ep.APIKey)
+		}
+		out[i] = ep
+	}
+	return out
+}
+
+// getUpstreams returns the upstream endpoint pool with API keys masked.
+// "legacy" is true when UPSTREAM_ENDPOINTS is unset and the pool shown is the
+// one derived from API_BASE_URL / API_FALLBACK_URL / API_KEY.
+//
+//	GET /admin/config/upstreams
+func (h *ConfigHandler) getUpstreams(c *gin.Context) {
+	eps, legacy, err := h.currentUpstreams(c.Request.Context())
+	if err != nil {
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", "config read failed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": maskEndpoints(eps), "total": len(eps), "legacy": legacy})
+}
+
+// updateUpstreams replaces the upstream pool. Body: a JSON array of
+// {"name","url","api_key","weight"}. An api_key that is omitted or still
+// masked (as returned by GET) keeps the endpoint's current key, matched by
+// name. proxy-svc picks the change up within the config cache window.
+//
+//	PUT /admin/config/upstreams
+func (h *ConfigHandler) updateUpstreams(c *gin.Context) {
+	var req []upstreams.Endpoint
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonErr(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	ctx := c.Request.Context()
+	current, _, err := h.currentUpstreams(ctx)
+	if err != nil {
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", "config read failed")
+		return
+	}
+	keys := make(map[string]string, len(current))
+	for _, ep := range current {
+		keys[ep.Name] = ep.APIKey
+	}
+	for i := range req {
+		if req[i].APIKey == "" || strings.Contains(req[i].APIKey, "***") {
+			req[i].APIKey = keys[req[i].Name]
+		}
+	}
+	if err := upstreams.Validate(req); err != nil {
+		jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS", err.Error())
+		return
+	}
+
+	claims := mw.GetAdminClaims(c)
+	updatedBy := ""
+	if claims != nil {
+		updatedBy = claims.Username
+	}
+	if err := h.cfgSvc.Set(ctx, upstreams.ConfigKey, upstreams.Encode(req), updatedBy); err != nil {
+		h.log.Error("update upstreams", zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update "+upstreams.ConfigKey)
+		return
+	}
+	if claims != nil {
+		go auditLog(context.Background(), h.q, claims.Subject, "CONFIG_UPDATE",
+			ptrStr(upstreams.ConfigKey), ptrStr(upstreams.Encode(maskEndpoints(current))),
+			ptrStr(upstreams.Encode(maskEndpoints(req))), c.ClientIP())
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": len(req)})
+}
+
+// ── Proxy TTL ─────────────────────────────────────────────────────────────────
+
+// proxyTTLEntry is one row of the proxy cache TTL table, in seconds.
+type proxyTTLEntry struct {
+	Path      string `json:"path"`
+	Default   int64  `json:"default"`
+	Override  *int64 `json:"override"` // nil when the default applies
+	Effective int64  `json:"effective"`
+}
+
+// getProxyTTL returns every proxy-svc cache TTL with its default and override.
+//
+//	GET /admin/config/proxy-ttl
+func (h *ConfigHandler) getProxyTTL(c *gin.Context) {
+	raw, err := h.cfgSvc.Get(c.Request.Context(), proxyttl.ConfigKey)
+	if err != nil && !errors.Is(err, config.ErrConfigNotFound) {
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", "config read failed")
+		return
+	}
+	overrides, err := proxyttl.Parse(raw)
+	if err != nil {
+		// Stored value is unusable; proxy-svc ignores it too.
+		h.log.Warn("invalid stored PROXY_TTLS", zap.Error(err))
+		overrides = nil
+	}
+	paths := proxyttl.Paths()
+	entries := make([]proxyTTLEntry, 0, len(paths))
+	for _, p := range paths {
+		e := proxyTTLEntry{Path: p, Default: int64(proxyttl.Defaults[p].Seconds())}
+		e.Effective = e.Default
+		if ttl, ok := overrides[p]; ok {
+			sec := int64(ttl.Seconds())
+			e.Override, e.Effective = &sec, sec
+		}
+		entries = append(entries, e)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries, "total": len(entries)})
+}
+
+// updateProxyTTL replaces the TTL overrides. Body: {"<path>": "<duration>"},
+// durations as Go strings ("10m") or integer seconds; 0 disables caching.
+// Paths omitted from the body revert to their default; {} clears all
+// overrides. proxy-svc picks the change up within the config cache window.
+//
+//	PUT /admin/config/proxy-ttl
+func (h *ConfigHandler) updateProxyTTL(c *gin.Context) {
+	var req map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonErr(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	body, _ := json.Marshal(req)
+	overrides, err := proxyttl.Parse(string(body))
+	if err != nil {
+		jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS", err.Error())
+		return
+	}
+	ctx := c.Request.Context()
+	claims := mw.GetAdminClaims(c)
+	updatedBy := ""
+	if claims != nil {
+		updatedBy = claims.Username
+	}
+
+	before, _ := h.cfgSvc.Get(ctx, proxyttl.ConfigKey)
+	after := proxyttl.Encode(overrides)
+	if err := h.cfgSvc.Set(ctx, proxyttl.ConfigKey, after, updatedBy); err != nil {
+		h.log.Error("update proxy ttl", zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update "+proxyttl.ConfigKey)
+		return
+	}
+	if claims != nil {
+		go auditLog(context.Background(), h.q, claims.Subject, "CONFIG_UPDATE",
+			ptrStr(proxyttl.ConfigKey), ptrStr(before), ptrStr(after), c.ClientIP())
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": len(overrides)})
+}
+
 // testAPIConnection sends a connectivity probe to the upstream music API.
 func (h *ConfigHandler) testAPIConnection(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -2,15 +2,26 @@
 package handler
 
 import (
+	"context"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go.uber.org/zap"
 
 	mw "listen-stream/admin-svc/internal/middleware"
 	"listen-stream/admin-svc/internal/repo"
 )
 
+const (
+	// cfgProxyLogRetentionDays is the default retention for DELETE /admin/logs/proxy;
+	// proxy-svc prunes with the same value hourly.
+	cfgProxyLogRetentionDays     = "PROXY_LOG_RETENTION_DAYS"
+	defaultProxyLogRetentionDays = 30
+)
+
 // LogHandler serves admin audit log endpoints.
 type LogHandler struct{ *Base }
 
@@ -22,6 +33,7 @@ func (h *LogHandler) Register(rg *gin.RouterGroup) {
 	auth := mw.RequireAdmin(h.jwtSvc)
 	rg.GET("/operations", auth, h.listOperationLogs)
 	rg.GET("/proxy", auth, h.listProxyLogs)
+	rg.DELETE("/proxy", auth, mw.RequireRole("SUPER_ADMIN"), h.pruneProxyLogs)
 }
 
 // listOperationLogs returns paginated admin operation logs.
@@ -51,9 +63,127 @@ func (h *LogHandler) listOperationLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": logs, "total": total, "page": page, "size": size})
 }
 
-// listProxyLogs is a stub; proxy_logs table is not in the current schema.
+// listProxyLogs returns paginated proxy access logs, newest first.
+// status is an exact HTTP status, path an upstream path prefix, and from/to
+// an RFC 3339 time range (from inclusive, to exclusive).
 //
-//	GET /admin/logs/proxy?page=&size=&status=
+//	GET /admin/logs/proxy?page=&size=&status=&path=&from=&to=
 func (h *LogHandler) listProxyLogs(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"data": []interface{}{}, "total": 0})
+	page, size := intPage(c)
+
+	var status int32
+	if v := c.Query("status"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 100 || n > 599 {
+			jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS", "status must be an HTTP status code")
+			return
+		}
+		status = int32(n)
+	}
+	since, err := queryTime(c, "from")
+	if err != nil {
+		jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS", "from must be RFC 3339")
+		return
+	}
+	until, err := queryTime(c, "to")
+	if err != nil {
+		jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS", "to must be RFC 3339")
+		return
+	}
+	path := c.Query("path")
+
+	ctx := c.Request.Context()
+	total, err := h.q.CountProxyLogs(ctx, repo.CountProxyLogsParams{
+		Status: status,
+		Path:   path,
+		Since:  since,
+		Until:  until,
+	})
+	if err != nil {
+		h.log.Error("count proxy logs", zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	logs, err := h.q.ListProxyLogs(ctx, repo.ListProxyLogsParams{
+		Limit:  size,
+		Offset: (page - 1) * size,
+		Status: status,
+		Path:   path,
+		Since:  since,
+		Until:  until,
+	})
+	if err != nil {
+		h.log.Error("list proxy logs", zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if logs == nil {
+		logs = []repo.ProxyLog{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": logs, "total": total, "page": page, "size": size})
+}
+
+// pruneProxyLogs drops daily proxy_logs partitions older than retention_days
+// (default PROXY_LOG_RETENTION_DAYS). SUPER_ADMIN only. When the setting is
+// 0 (keep all, as proxy-svc reads it), retention_days must be given.
+//
+//	DELETE /admin/logs/proxy?retention_days=
+func (h *LogHandler) pruneProxyLogs(c *gin.Context) {
+	ctx := c.Request.Context()
+	days := h.proxyLogRetention(ctx)
+	if v := c.Query("retention_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS", "retention_days must be a positive integer")
+			return
+		}
+		days = n
+	}
+	if days == 0 {
+		jsonErr(c, http.StatusBadRequest, "INVALID_PARAMS",
+			"PROXY_LOG_RETENTION_DAYS is 0 (keep all); pass retention_days to prune")
+		return
+	}
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -days)
+	dropped, err := h.q.DropProxyLogsBefore(ctx, pgtype.Date{Time: cutoff, Valid: true})
+	if err != nil {
+		h.log.Error("prune proxy logs", zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	if claims := mw.GetAdminClaims(c); claims != nil {
+		after := strconv.Itoa(days)
+		go auditLog(context.Background(), h.q, claims.Subject, "PROXY_LOGS_PRUNED", nil, nil, &after, c.ClientIP())
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"dropped_partitions": dropped,
+		"retention_days":     days,
+		"cutoff":             cutoff.Format(time.DateOnly),
+	})
+}
+
+// proxyLogRetention reads PROXY_LOG_RETENTION_DAYS the way proxy-svc does:
+// 0 means keep all, anything invalid the default.
+func (h *LogHandler) proxyLogRetention(ctx context.Context) int {
+	if v, err := h.cfgSvc.Get(ctx, cfgProxyLogRetentionDays); err == nil {
+		if n, perr := strconv.Atoi(v); perr == nil && n >= 0 {
+			return n
+		}
+	}
+	return defaultProxyLogRetentionDays
+}
+
+// queryTime parses an optional RFC 3339 query param; absent means NULL.
+func queryTime(c *gin.Context, name string) (pgtype.Timestamptz, error) {
+	v := c.Query(name)
+	if v == "" {
+		return pgtype.Timestamptz{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return pgtype.Timestamptz{}, err
+	}
+	return pgtype.Timestamptz{Time: t, Valid: true}, nil
 }
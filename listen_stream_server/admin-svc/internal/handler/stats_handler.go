@@ -2,7 +2,9 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +13,7 @@ import (
 
 	mw "listen-stream/admin-svc/internal/middleware"
 	"listen-stream/shared/pkg/rdb"
+	"listen-stream/shared/pkg/upstreams"
 )
 
 // StatsHandler serves admin overview stats.
@@ -22,6 +25,8 @@ func NewStatsHandler(b *Base) *StatsHandler { return &StatsHandler{b} }
 // Register mounts stats routes.
 func (h *StatsHandler) Register(rg *gin.RouterGroup) {
 	rg.GET("/overview", mw.RequireAdmin(h.jwtSvc), h.overview)
+	rg.GET("/upstream", mw.RequireAdmin(h.jwtSvc), h.upstream)
+	rg.GET("/warmer", mw.RequireAdmin(h.jwtSvc), h.warmer)
 }
 
 // overview returns aggregate system statistics.
@@ -59,3 +64,102 @@ func (h *StatsHandler) overview(c *gin.Context) {
 		"cookie_alert":     cookieAlert,
 	})
 }
+
+// upstream returns the circuit-breaker state of each upstream base URL on
+// each proxy-svc replica ("data", one row per instance and URL, with an
+// "open_instances" count per URL in "summary") and the endpoint pool's
+// health, latency and traffic share ("pool"), as last published by proxy-svc.
+//
+//	GET /admin/stats/upstream
+func (h *StatsHandler) upstream(c *gin.Context) {
+	ctx := c.Request.Context()
+	fields, err := h.rdb.HGetAll(ctx, rdb.KeyUpstreamBreakers())
+	if err != nil {
+		h.log.Error("read upstream breakers", zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	poolFields, err := h.rdb.HGetAll(ctx, rdb.KeyUpstreamPool())
+	if err != nil {
+		h.log.Error("read upstream pool", zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	pool := make([]upstreams.Status, 0, len(poolFields))
+	for _, v := range poolFields {
+		var st upstreams.Status
+		if err := json.Unmarshal([]byte(v), &st); err == nil {
+			pool = append(pool, st)
+		}
+	}
+	sort.Slice(pool, func(i, j int) bool { return pool[i].Name < pool[j].Name })
+
+	breakers := make([]map[string]interface{}, 0, len(fields))
+	for _, v := range fields {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &m); err == nil {
+			breakers = append(breakers, m)
+		}
+	}
+	sort.Slice(breakers, func(i, j int) bool {
+		bi, _ := breakers[i]["base_url"].(string)
+		bj, _ := breakers[j]["base_url"].(string)
+		if bi != bj {
+			return bi < bj
+		}
+		ii, _ := breakers[i]["instance"].(string)
+		ij, _ := breakers[j]["instance"].(string)
+		return ii < ij
+	})
+
+	// Per base URL: how many replicas report it, and how many have it open.
+	type breakerSummary struct {
+		BaseURL       string `json:"base_url"`
+		Instances     int    `json:"instances"`
+		OpenInstances int    `json:"open_instances"`
+	}
+	summary := make([]breakerSummary, 0)
+	for _, b := range breakers {
+		url, _ := b["base_url"].(string)
+		if n := len(summary); n == 0 || summary[n-1].BaseURL != url {
+			summary = append(summary, breakerSummary{BaseURL: url})
+		}
+		s := &summary[len(summary)-1]
+		s.Instances++
+		if state, _ := b["state"].(string); state != "closed" {
+			s.OpenInstances++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": breakers, "total": len(breakers), "summary": summary, "pool": pool})
+}
+
+// warmer returns the last cache-warm result for each warmed entry,
+// as published by proxy-svc's cache warm cron.
+//
+//	GET /admin/stats/warmer
+func (h *StatsHandler) warmer(c *gin.Context) {
+	fields, err := h.rdb.HGetAll(c.Request.Context(), rdb.KeyCacheWarmStatus())
+	if err != nil {
+		h.log.Error("read cache warm status", zap.Error(err))
+		jsonErr(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	entries := make([]map[string]interface{}, 0, len(keys))
+	failed := 0
+	for _, k := range keys {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(fields[k]), &m); err != nil {
+			continue
+		}
+		if ok, _ := m["ok"].(bool); !ok {
+			failed++
+		}
+		entries = append(entries, m)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries, "total": len(entries), "failed": failed})
+}
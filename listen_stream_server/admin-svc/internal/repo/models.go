@@ -110,6 +110,18 @@ type PlaylistSong struct {
 	AddedAt    pgtype.Timestamptz `json:"added_at"`
 }
 
+type ProxyLog struct {
+	ID          int64              `json:"id"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UserID      *string            `json:"user_id"`
+	Path        string             `json:"path"`
+	QueryHash   string             `json:"query_hash"`
+	CacheStatus string             `json:"cache_status"`
+	Upstream    string             `json:"upstream"`
+	Status      int32              `json:"status"`
+	LatencyMs   int32              `json:"latency_ms"`
+}
+
 type SystemConfig struct {
 	Key       string             `json:"key"`
 	Value     string             `json:"value"`
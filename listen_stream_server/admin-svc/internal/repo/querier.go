@@ -16,6 +16,7 @@ type Querier interface {
 	// 初始化检查：count > 0 表示已初始化
 	CountAdminUsers(ctx context.Context) (int64, error)
 	CountOperationLogs(ctx context.Context, action string) (int64, error)
+	CountProxyLogs(ctx context.Context, arg CountProxyLogsParams) (int64, error)
 	CountTotalDevices(ctx context.Context) (int64, error)
 	CountUserDevices(ctx context.Context, userID string) (int64, error)
 	CountUsers(ctx context.Context) (int64, error)
@@ -28,6 +29,8 @@ type Querier interface {
 	// 禁用用户时吊销全部设备
 	DeleteAllUserDevices(ctx context.Context, userID string) error
 	DeleteDevice(ctx context.Context, deviceID string) error
+	// 保留期清理：删除 cutoff 之前的日分区，返回删除的分区数
+	DropProxyLogsBefore(ctx context.Context, cutoff pgtype.Date) (int32, error)
 	GetAdminByID(ctx context.Context, id string) (AdminUser, error)
 	// ============================================================
 	// admin_users 查询
@@ -58,6 +61,11 @@ type Querier interface {
 	// ConfigService.Preload 启动时预热所有配置
 	ListAllConfigs(ctx context.Context) ([]SystemConfig, error)
 	ListOperationLogs(ctx context.Context, arg ListOperationLogsParams) ([]OperationLog, error)
+	// ============================================================
+	// proxy_logs 查询（分区表，proxy-svc 批量 COPY 写入）
+	// 使用服务：admin-svc（读查询 + 保留期清理）
+	// ============================================================
+	ListProxyLogs(ctx context.Context, arg ListProxyLogsParams) ([]ProxyLog, error)
 	ListUserDevices(ctx context.Context, userID string) ([]Device, error)
 	// Admin 分页查询，支持手机号前缀搜索
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
@@ -19,7 +19,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 
@@ -29,7 +28,9 @@ import (
 	"listen-stream/auth-svc/internal/service/sms"
 	"listen-stream/shared/pkg/config"
 	"listen-stream/shared/pkg/crypto"
+	"listen-stream/shared/pkg/metrics"
 	"listen-stream/shared/pkg/rdb"
+	"listen-stream/shared/pkg/tracing"
 )
 
 func main() {
@@ -43,9 +44,15 @@ func main() {
 	}
 	defer logger.Sync() //nolint:errcheck
 
+	// Tracing: exporter chosen by OTEL_TRACES_EXPORTER (default none).
+	shutdownTracing, err := tracing.Setup(context.Background(), "auth-svc")
+	if err != nil {
+		logger.Fatal("init tracing", zap.Error(err))
+	}
+
 	// ── 3. PostgreSQL ─────────────────────────────────────────────────────────
 	dbURL := mustEnv("DATABASE_URL")
-	pool, err := pgxpool.New(context.Background(), dbURL)
+	pool, err := tracing.NewPool(context.Background(), dbURL)
 	if err != nil {
 		logger.Fatal("connect postgres", zap.Error(err))
 	}
@@ -83,7 +90,7 @@ func main() {
 
 	// ── 7. HTTP routes ─────────────────────────────────────────────────────────
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(metrics.Middleware(), tracing.Middleware("auth-svc"), gin.Recovery())
 	r.GET("/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
 	authHandler.Register(r.Group("/auth"))
 
@@ -98,6 +105,15 @@ func main() {
 		}
 	}()
 
+	// Prometheus scrape endpoint on its own listener, kept off the public port.
+	metricsSrv := metrics.NewServer(envOr("METRICS_ADDR", ":9001"))
+	go func() {
+		logger.Info("auth-svc metrics listening", zap.String("addr", metricsSrv.Addr))
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server error", zap.Error(err))
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -108,6 +124,8 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("graceful shutdown failed", zap.Error(err))
 	}
+	_ = metricsSrv.Shutdown(ctx)
+	_ = shutdownTracing(ctx)
 }
 
 func mustEnv(key string) string {
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"listen-stream/shared/pkg/metrics"
+)
+
+var (
+	// smsSends counts SendCode outcomes: sent, rate_limited, delivery_failed
+	// (the provider rejected or failed the send) or error (Redis, code generation).
+	smsSends = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "sms",
+		Name:      "send_total",
+		Help:      "SMS verification code sends by result.",
+	}, []string{"result"})
+
+	// smsVerifies counts VerifyCode outcomes: ok, invalid, expired or error.
+	smsVerifies = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "sms",
+		Name:      "verify_total",
+		Help:      "SMS verification code checks by result.",
+	}, []string{"result"})
+)
+
+func sendResult(err error) string {
+	var rl ErrRateLimited
+	switch {
+	case err == nil:
+		return "sent"
+	case errors.As(err, &rl):
+		return "rate_limited"
+	case errors.Is(err, ErrSMSDelivery):
+		return "delivery_failed"
+	}
+	return "error"
+}
+
+func verifyResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrInvalidCode):
+		return "invalid"
+	case errors.Is(err, ErrCodeExpired):
+		return "expired"
+	}
+	return "error"
+}
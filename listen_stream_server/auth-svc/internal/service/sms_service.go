@@ -77,9 +77,11 @@ func NewSMSService(adapter sms.Adapter, rdbClient *rdb.Client, log *zap.Logger)
 
 // SendCode generates a verification code, rate-limits the phone number, stores
 // it in Redis, and delivers it via the configured SMS adapter.
-func (s *SMSService) SendCode(ctx context.Context, phone string) error {
+func (s *SMSService) SendCode(ctx context.Context, phone string) (err error) {
+	defer func() { smsSends.WithLabelValues(sendResult(err)).Inc() }()
+
 	// Check rate limit: if key exists, a code was already sent within 60 s.
-	_, err := s.rdb.Get(ctx, rdb.KeySMSLimit(phone))
+	_, err = s.rdb.Get(ctx, rdb.KeySMSLimit(phone))
 	if err == nil {
 		// Key exists — rate limited. Return TTL as retryAfter.
 		ttl, _ := s.rdb.TTL(ctx, rdb.KeySMSLimit(phone))
@@ -138,7 +140,9 @@ func (s *SMSService) writeDevLog(ctx context.Context, phone, code string) {
 
 // VerifyCode validates the one-time code and removes it from Redis on success.
 // Returns ErrCodeExpired, ErrInvalidCode, or nil.
-func (s *SMSService) VerifyCode(ctx context.Context, phone, input string) error {
+func (s *SMSService) VerifyCode(ctx context.Context, phone, input string) (err error) {
+	defer func() { smsVerifies.WithLabelValues(verifyResult(err)).Inc() }()
+
 	stored, err := s.rdb.Get(ctx, rdb.KeySMSCode(phone))
 	if err != nil {
 		if err == goredis.Nil {
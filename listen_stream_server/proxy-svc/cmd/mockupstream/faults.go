@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// faultRule injects a failure into matching requests. Rules are checked in
+// order; every firing rule adds its Delay, and the first firing rule with an
+// outcome (Status, BadJSON, AuthExpired) decides the answer. Example script:
+//
+//	[
+//	  {"path": "/search/", "delay": "2s"},
+//	  {"path": "/song/url", "status": 503, "times": 3},
+//	  {"query": "types=url", "bad_json": true, "rate": 0.5},
+//	  {"path": "/recommend/daily", "auth_expired": true}
+//	]
+type faultRule struct {
+	Path  string `json:"path,omitempty"`  // request path prefix; empty matches every path
+	Query string `json:"query,omitempty"` // substring of the raw query, e.g. "types=url"
+
+	Delay       duration `json:"delay,omitempty"`        // added latency, e.g. "1500ms"
+	Status      int      `json:"status,omitempty"`       // answer this status (e.g. 503) instead
+	BadJSON     bool     `json:"bad_json,omitempty"`     // answer 200 with the body cut in half
+	AuthExpired bool     `json:"auth_expired,omitempty"` // answer upstream's cookie-rejected envelope
+
+	Rate  float64 `json:"rate,omitempty"`  // chance of firing per match, (0, 1]; 0 means always
+	Times int     `json:"times,omitempty"` // fire this many more times, then drop the rule; 0 means forever
+}
+
+func (f *faultRule) validate() error {
+	switch {
+	case f.Rate < 0 || f.Rate > 1:
+		return fmt.Errorf("rate must be within [0, 1]")
+	case f.Times < 0:
+		return fmt.Errorf("times must not be negative")
+	case f.Status != 0 && (f.Status < 400 || f.Status > 599):
+		return fmt.Errorf("status must be a 4xx or 5xx code")
+	case f.Delay <= 0 && f.Status == 0 && !f.BadJSON && !f.AuthExpired:
+		return fmt.Errorf("rule has no effect: set delay, status, bad_json or auth_expired")
+	}
+	return nil
+}
+
+func (f *faultRule) matches(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, f.Path) && strings.Contains(r.URL.RawQuery, f.Query)
+}
+
+func (f *faultRule) hasOutcome() bool {
+	return f.Status != 0 || f.BadJSON || f.AuthExpired
+}
+
+// duration is a time.Duration that reads and writes as "1500ms".
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("delay must be a duration string such as \"500ms\"")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// faultScript is the current rule list. It serves /_mock/faults:
+//
+//	GET    — list the rules (with the Times left)
+//	PUT    — replace the rules with a JSON array
+//	POST   — append one rule
+//	DELETE — remove every rule
+type faultScript struct {
+	mu    sync.Mutex
+	rules []*faultRule
+}
+
+func (s *faultScript) load(raw []byte) error {
+	var rules []*faultRule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return err
+	}
+	for i, f := range rules {
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	s.mu.Lock()
+	s.rules = rules
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *faultScript) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		rules := append([]*faultRule{}, s.rules...)
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, rules)
+	case http.MethodPut:
+		raw, _ := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err := s.load(raw); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPost:
+		var f faultRule
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&f); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := f.validate(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.mu.Lock()
+		s.rules = append(s.rules, &f)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		s.mu.Lock()
+		s.rules = nil
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// fire returns the total delay and the deciding rule (nil when the request is
+// served normally) for r, using up one of each firing rule's Times.
+func (s *faultScript) fire(r *http.Request) (time.Duration, *faultRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var (
+		delay   time.Duration
+		outcome *faultRule
+		keep    = s.rules[:0]
+	)
+	for _, f := range s.rules {
+		fired := f.matches(r) && (f.Rate == 0 || rand.Float64() < f.Rate) &&
+			(f.Delay > 0 || (outcome == nil && f.hasOutcome()))
+		if fired {
+			delay += time.Duration(f.Delay)
+			if outcome == nil && f.hasOutcome() {
+				c := *f
+				outcome = &c
+			}
+			if f.Times > 0 {
+				if f.Times--; f.Times == 0 {
+					continue // used up
+				}
+			}
+		}
+		keep = append(keep, f)
+	}
+	clear(s.rules[len(keep):])
+	s.rules = keep
+	return delay, outcome
+}
+
+// wrap applies the script in front of next.
+func (s *faultScript) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay, f := s.fire(r)
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		switch {
+		case f == nil:
+			next.ServeHTTP(w, r)
+		case f.Status != 0:
+			log.Printf("fault: %d for %s", f.Status, r.URL.RequestURI())
+			writeJSON(w, f.Status, envelope(0, "mockupstream: injected fault", nil))
+		case f.AuthExpired:
+			log.Printf("fault: cookie rejected for %s", r.URL.RequestURI())
+			writeJSON(w, http.StatusOK, cookieRejected)
+		case f.BadJSON:
+			log.Printf("fault: bad json for %s", r.URL.RequestURI())
+			rec := &bufferedResponse{header: make(http.Header)}
+			next.ServeHTTP(rec, r)
+			for k, vv := range rec.header {
+				w.Header()[k] = vv
+			}
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(rec.body.Bytes()[:rec.body.Len()/2])
+		}
+	})
+}
+
+// bufferedResponse collects a handler's answer so it can be corrupted.
+type bufferedResponse struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(int)             {}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// fixtureRoutes maps each upstream path proxy-svc calls to its captured
+// payload. Query parameters are ignored except /search/'s type.
+var fixtureRoutes = map[string]string{
+	"/recommend/banner":     "recommend_banner.json",
+	"/recommend/daily":      "recommend_daily.json",
+	"/recommend/playlist":   "recommend_playlist.json",
+	"/recommend/new/songs":  "recommend_new_songs.json",
+	"/recommend/new/albums": "recommend_new_albums.json",
+	"/playlist/category":    "playlist_category.json",
+	"/playlist/information": "playlist_information.json",
+	"/playlist/detail":      "playlist_detail.json",
+	"/artist/category":      "singer_filter.json",
+	"/artist/list":          "singer_filter_list.json",
+	"/artist/detail":        "singer_detail.json",
+	"/artist/albums":        "singer_albums.json",
+	"/artist/mvs":           "singer_mvs.json",
+	"/artist/songs":         "singer_songs.json",
+	"/rankings/list":        "ranking_list.json",
+	"/rankings/detail":      "ranking_detail.json",
+	"/radio/category":       "radio_list.json",
+	"/radio/songlist":       "radio_songs.json",
+	"/mv/category":          "mv_category.json",
+	"/mv/list":              "mv_category_list.json",
+	"/mv/detail":            "mv_detail.json",
+	"/album/detail":         "album_detail.json",
+	"/album/songs":          "album_songs.json",
+	"/song/detail":          "song_detail.json",
+	"/search/hotkey":        "search_hot_key.json",
+	"/lyric/":               "lyric.json",
+}
+
+// searchFixtures maps the /search/ type parameter to its payload; an
+// unknown or missing type searches songs, as upstream does.
+var searchFixtures = map[string]string{
+	"0":  "search_song.json",
+	"9":  "search_singer.json",
+	"8":  "search_albums.json",
+	"12": "search_mv.json",
+}
+
+// fixtureSet is every payload read from the fixture directory.
+type fixtureSet struct {
+	byFile map[string][]byte
+}
+
+// loadFixtures reads every file named in fixtureRoutes and searchFixtures
+// from dir and checks it is valid JSON, so a broken capture fails at startup.
+func loadFixtures(dir string) (*fixtureSet, error) {
+	fs := &fixtureSet{byFile: make(map[string][]byte)}
+	files := make([]string, 0, len(fixtureRoutes)+len(searchFixtures))
+	for _, f := range fixtureRoutes {
+		files = append(files, f)
+	}
+	for _, f := range searchFixtures {
+		files = append(files, f)
+	}
+	for _, f := range files {
+		if _, ok := fs.byFile[f]; ok {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(raw) {
+			return nil, fmt.Errorf("%s: invalid JSON", f)
+		}
+		fs.byFile[f] = raw
+	}
+	return fs, nil
+}
+
+// lookup returns the payload for path. "/lyric" and "/search" are accepted
+// without the trailing slash.
+func (fs *fixtureSet) lookup(path string, q url.Values) ([]byte, bool) {
+	if path == "/search/" || path == "/search" {
+		f, ok := searchFixtures[q.Get("type")]
+		if !ok {
+			f = searchFixtures["0"]
+		}
+		return fs.byFile[f], true
+	}
+	if path == "/lyric" {
+		path = "/lyric/"
+	}
+	f, ok := fixtureRoutes[path]
+	if !ok {
+		return nil, false
+	}
+	return fs.byFile[f], true
+}
+
+// songHit is a song from the search fixture, used to answer Joox searches.
+type songHit struct {
+	MID      string
+	Name     string
+	Artists  []string
+	Album    string
+	AlbumMID string
+	Duration int
+}
+
+// songs returns the songs in the captured song search.
+func (fs *fixtureSet) songs() []songHit {
+	var doc struct {
+		Data struct {
+			List []struct {
+				SongMID   string `json:"songmid"`
+				SongName  string `json:"songname"`
+				AlbumName string `json:"albumname"`
+				AlbumMID  string `json:"albummid"`
+				Interval  int    `json:"interval"`
+				Singer    []struct {
+					Name string `json:"name"`
+				} `json:"singer"`
+			} `json:"list"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(fs.byFile[searchFixtures["0"]], &doc) != nil {
+		return nil
+	}
+	out := make([]songHit, 0, len(doc.Data.List))
+	for _, s := range doc.Data.List {
+		h := songHit{MID: s.SongMID, Name: s.SongName, Album: s.AlbumName, AlbumMID: s.AlbumMID, Duration: s.Interval}
+		for _, a := range s.Singer {
+			h.Artists = append(h.Artists, a.Name)
+		}
+		out = append(out, h)
+	}
+	return out
+}
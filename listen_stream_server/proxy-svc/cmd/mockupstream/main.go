@@ -0,0 +1,203 @@
+// cmd/mockupstream/main.go — fixture-backed stand-in for the third-party music
+// API, for local development and end-to-end tests of proxy-svc.
+//
+// Usage (from listen_stream_server/proxy-svc):
+//
+//	go run ./cmd/mockupstream --addr=:9100 --fixtures=../../api
+//
+// then point proxy-svc at it, e.g. API_BASE_URL=http://localhost:9100 and
+// API_FALLBACK_URL=http://localhost:9100 (run a second instance on another
+// port to exercise fallback between endpoints).
+//
+// It serves:
+//   - the captured payloads in api/*.json on the upstream paths proxy-svc
+//     calls (see "api/ api.md"); /search/ picks the fixture by ?type=;
+//   - /song/url with a signed, expiring URL, or the VIP-only failure for
+//     --vip song mids;
+//   - Joox-style fallback answers on ?types=search|url;
+//   - /audio/{file}, the audio those URLs point at (Range-capable, 403 once
+//     the URL's expire has passed).
+//
+// Faults (latency, 5xx, bad JSON, cookie rejection) are scripted through
+// --faults=file.json or at runtime via /_mock/faults; request counts per path
+// are at /_mock/stats. See faults.go for the rule format.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":9100", "listen address")
+	fixtureDir := flag.String("fixtures", "../../api", "directory holding the captured *.json payloads")
+	faultFile := flag.String("faults", "", "optional JSON file of fault rules to start with")
+	vip := flag.String("vip", "00061J2t0b0PPW", "comma-separated song mids that /song/url refuses (VIP-only), to exercise the Joox fallback")
+	requireCookie := flag.Bool("require-cookie", false, "reject /song/url and /recommend/daily without a Cookie header")
+	urlTTL := flag.Duration("url-ttl", 10*time.Minute, "lifetime of the signed audio URLs handed out")
+	jooxMaxBR := flag.Int("joox-max-br", 999, "highest bitrate Joox reports; lower it to exercise quality downgrades")
+	rejectedMsg := flag.String("cookie-rejected-message", cookieRejectedPlaceholder,
+		"message of the cookie-rejected envelope; the default is a placeholder, not upstream's real text")
+	flag.Parse()
+	cookieRejected = envelope(0, *rejectedMsg, []any{})
+
+	fixtures, err := loadFixtures(*fixtureDir)
+	if err != nil {
+		log.Fatalf("load fixtures: %v", err)
+	}
+	faults := &faultScript{}
+	if *faultFile != "" {
+		raw, err := os.ReadFile(*faultFile)
+		if err != nil {
+			log.Fatalf("read faults: %v", err)
+		}
+		if err := faults.load(raw); err != nil {
+			log.Fatalf("parse faults: %v", err)
+		}
+	}
+
+	srv := &server{
+		fixtures:      fixtures,
+		vip:           make(map[string]bool),
+		requireCookie: *requireCookie,
+		urlTTL:        *urlTTL,
+		jooxMaxBR:     *jooxMaxBR,
+		audio:         newAudio(),
+		hits:          make(map[string]int),
+	}
+	for _, mid := range strings.Split(*vip, ",") {
+		if mid = strings.TrimSpace(mid); mid != "" {
+			srv.vip[mid] = true
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/_mock/faults", faults)
+	mux.HandleFunc("/_mock/stats", srv.stats)
+	mux.Handle("/", faults.wrap(srv.count(http.HandlerFunc(srv.route))))
+
+	log.Printf("mockupstream listening on %s (%d fixtures from %s)", *addr, len(fixtures.byFile), *fixtureDir)
+	if err := http.ListenAndServe(*addr, logRequests(mux)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// server holds the fixtures and the generated-response settings.
+type server struct {
+	fixtures      *fixtureSet
+	vip           map[string]bool
+	requireCookie bool
+	urlTTL        time.Duration
+	jooxMaxBR     int
+	audio         *audio
+
+	mu   sync.Mutex
+	hits map[string]int // request path → count, for /_mock/stats
+}
+
+// route dispatches an upstream request. The Joox fallback API has no path of
+// its own (API_FALLBACK_URL + "?types=…"), so any request with ?types= is Joox.
+func (s *server) route(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, envelope(0, "method not allowed", nil))
+		return
+	}
+	q := r.URL.Query()
+	switch {
+	case q.Has("types"):
+		s.joox(w, r)
+	case r.URL.Path == "/song/url":
+		s.songURL(w, r)
+	case strings.HasPrefix(r.URL.Path, "/audio/"):
+		s.audio.serve(w, r)
+	default:
+		if s.requireCookie && r.URL.Path == "/recommend/daily" && r.Header.Get("Cookie") == "" {
+			writeJSON(w, http.StatusOK, cookieRejected)
+			return
+		}
+		body, ok := s.fixtures.lookup(r.URL.Path, q)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, envelope(0, "no fixture for "+r.URL.Path, nil))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(body)
+	}
+}
+
+// count records each request path for /_mock/stats.
+func (s *server) count(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits[r.URL.Path]++
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// stats answers GET with the request count per path; DELETE resets it.
+func (s *server) stats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.hits)
+	case http.MethodDelete:
+		s.hits = make(map[string]int)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// cookieRejectedPlaceholder is the message of the mock's cookie-rejected
+// envelope. It is a PLACEHOLDER, not captured from upstream: api.md and the
+// fixtures do not show what the real API answers for an expired cookie.
+// Nothing may treat it as upstream's contract; proxy-svc only matches it
+// because it is also the unconfirmed default of
+// UPSTREAM_AUTH_EXPIRED_MESSAGES. Once the real response is known, replace
+// it here and there, or pass --cookie-rejected-message.
+const cookieRejectedPlaceholder = "login expired, cookie invalid"
+
+// cookieRejected is the envelope served for a rejected login cookie; main
+// sets it from --cookie-rejected-message.
+var cookieRejected = envelope(0, cookieRejectedPlaceholder, []any{})
+
+// envelope builds the upstream {"code","message","data"} wrapper.
+func envelope(code int, message string, data any) map[string]any {
+	return map[string]any{"code": code, "message": message, "data": data}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false) // keep "&" in URLs readable, as upstream does
+	_ = enc.Encode(v)
+}
+
+// statusRecorder captures the status code for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("%s %s %d %s", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// qqFiles maps the /song/url type parameter to the file name prefix and
+// extension upstream uses for that quality.
+var qqFiles = map[string]struct{ prefix, ext string }{
+	"":     {"C400", "m4a"},
+	"320":  {"M800", "mp3"},
+	"flac": {"F000", "flac"},
+}
+
+// songURL answers /song/url?id=…[&type=320|flac] like upstream: a signed
+// audio URL for any song except --vip ones, which get the VIP-only failure.
+func (s *server) songURL(w http.ResponseWriter, r *http.Request) {
+	if s.requireCookie && r.Header.Get("Cookie") == "" {
+		writeJSON(w, http.StatusOK, cookieRejected)
+		return
+	}
+	q := r.URL.Query()
+	mid := q.Get("id")
+	if mid == "" {
+		writeJSON(w, http.StatusOK, envelope(0, "id is required", []any{}))
+		return
+	}
+	if s.vip[mid] {
+		writeJSON(w, http.StatusOK, envelope(0, "VIP-only content or not available in your region", []any{}))
+		return
+	}
+	f, ok := qqFiles[q.Get("type")]
+	if !ok {
+		f = qqFiles[""]
+	}
+	filename := f.prefix + mid + "." + f.ext
+	vkey := sign(filename)
+	writeJSON(w, http.StatusOK, envelope(1, "Success", map[string]any{
+		"url":      s.audioURL(r, filename, url.Values{"vkey": {vkey}, "guid": {"mockupstream"}}),
+		"songmid":  mid,
+		"vkey":     vkey,
+		"filename": filename,
+		"source":   "purl",
+	}))
+}
+
+// joox answers the fallback API: ?types=search&source=joox&name=… and
+// ?types=url&source=joox&id=…&br=….
+func (s *server) joox(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch q.Get("types") {
+	case "search":
+		s.jooxSearch(w, q.Get("name"))
+	case "url":
+		s.jooxURL(w, r, q.Get("id"), q.Get("br"))
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported types " + strconv.Quote(q.Get("types"))})
+	}
+}
+
+type jooxItem struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Artist   []string `json:"artist"`
+	Album    string   `json:"album"`
+	PicID    string   `json:"pic_id"`
+	URLID    string   `json:"url_id"`
+	LyricID  string   `json:"lyric_id"`
+	Source   string   `json:"source"`
+	From     string   `json:"from"`
+	Duration int      `json:"duration,omitempty"`
+}
+
+// jooxSearch returns the songs of the search fixture whose title contains
+// name (or the reverse), each followed by a live version so the match
+// scoring has a decoy to reject. A name matching nothing gets one
+// artist-less hit with the exact title, so any song can fall back.
+func (s *server) jooxSearch(w http.ResponseWriter, name string) {
+	name = strings.TrimSpace(name)
+	items := []jooxItem{}
+	if name == "" {
+		writeJSON(w, http.StatusOK, items)
+		return
+	}
+	want := strings.ToLower(name)
+	for _, h := range s.fixtures.songs() {
+		got := strings.ToLower(h.Name)
+		if !strings.Contains(got, want) && !strings.Contains(want, got) {
+			continue
+		}
+		item := jooxItem{
+			ID: "joox_" + h.MID, Name: h.Name, Artist: h.Artists, Album: h.Album,
+			PicID: h.AlbumMID, Source: "joox", From: "mockupstream", Duration: h.Duration,
+		}
+		item.URLID, item.LyricID = item.ID, item.ID
+		live := item
+		live.ID, live.URLID, live.LyricID = item.ID+"_live", item.ID+"_live", item.ID+"_live"
+		live.Name = h.Name + " (Live)"
+		live.Duration = h.Duration + 40
+		items = append(items, item, live)
+	}
+	if len(items) == 0 {
+		id := "joox_" + sign(name)[:12]
+		items = append(items, jooxItem{
+			ID: id, Name: name, Artist: []string{}, URLID: id, LyricID: id,
+			Source: "joox", From: "mockupstream",
+		})
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// jooxURL returns a signed URL at the requested bitrate, capped at
+// --joox-max-br as Joox silently does for tiers it lacks.
+func (s *server) jooxURL(w http.ResponseWriter, r *http.Request, id, brParam string) {
+	resp := map[string]any{"url": "", "br": 0, "size": 0, "from": "mockupstream"}
+	if id == "" {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	br, err := strconv.Atoi(brParam)
+	if err != nil || br <= 0 {
+		br = 320
+	}
+	br = min(br, s.jooxMaxBR)
+	ext := "mp3"
+	if br >= 700 {
+		ext = "flac"
+	}
+	filename := id + "_" + strconv.Itoa(br) + "." + ext
+	resp["url"] = s.audioURL(r, filename, nil)
+	resp["br"] = br
+	resp["size"] = len(s.audio.data)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// audioURL builds the /audio/ URL for filename on this server, valid for
+// --url-ttl (carried as a Unix "expire" parameter, like signed CDN URLs).
+func (s *server) audioURL(r *http.Request, filename string, extra url.Values) string {
+	v := url.Values{}
+	for k, vv := range extra {
+		v[k] = vv
+	}
+	v.Set("expire", strconv.FormatInt(time.Now().Add(s.urlTTL).Unix(), 10))
+	u := url.URL{Scheme: "http", Host: r.Host, Path: "/audio/" + filename, RawQuery: v.Encode()}
+	return u.String()
+}
+
+// sign derives a stable fake signature for a file name.
+func sign(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:16]))
+}
+
+// audioSize is the length of every served audio file.
+const audioSize = 512 << 10
+
+// audio serves the same deterministic bytes under any file name.
+type audio struct {
+	data    []byte
+	modTime time.Time
+}
+
+func newAudio() *audio {
+	data := make([]byte, audioSize)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+	return &audio{data: data, modTime: time.Now().Truncate(time.Second)}
+}
+
+var audioTypes = map[string]string{
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mpeg",
+	".flac": "audio/flac",
+}
+
+// serve answers /audio/{file}, honouring Range. A URL past its expire gets
+// 403, as the real audio hosts answer a stale signature.
+func (a *audio) serve(w http.ResponseWriter, r *http.Request) {
+	if exp, err := strconv.ParseInt(r.URL.Query().Get("expire"), 10, 64); err == nil && time.Now().Unix() > exp {
+		http.Error(w, "signature expired", http.StatusForbidden)
+		return
+	}
+	name := path.Base(r.URL.Path)
+	if ct, ok := audioTypes[path.Ext(name)]; ok {
+		w.Header().Set("Content-Type", ct)
+	}
+	http.ServeContent(w, r, name, a.modTime, bytes.NewReader(a.data))
+}
@@ -4,6 +4,8 @@
 //   - Authenticates requests using JWTs (no DB lookup — lightweight)
 //   - Caches upstream API responses in Redis with per-endpoint TTLs
 //   - Falls back to stale cache when the upstream is unavailable
+//   - Forwards auth-svc and sync-svc routes (including the /ws upgrade) so
+//     clients need only one origin
 package main
 
 import (
@@ -12,20 +14,26 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 
+	"listen-stream/proxy-svc/internal/accesslog"
+	proxycron "listen-stream/proxy-svc/internal/cron"
+	"listen-stream/proxy-svc/internal/gateway"
 	"listen-stream/proxy-svc/internal/handler"
 	proxymw "listen-stream/proxy-svc/internal/middleware"
+	"listen-stream/proxy-svc/internal/playback"
 	"listen-stream/proxy-svc/internal/upstream"
 	"listen-stream/shared/pkg/config"
 	"listen-stream/shared/pkg/crypto"
+	"listen-stream/shared/pkg/metrics"
 	"listen-stream/shared/pkg/rdb"
+	"listen-stream/shared/pkg/tracing"
 )
 
 func main() {
@@ -39,9 +47,15 @@ func main() {
 	}
 	defer logger.Sync() //nolint:errcheck
 
-	// ── 3. PostgreSQL (for ConfigService) ─────────────────────────────────────
+	// Tracing: exporter chosen by OTEL_TRACES_EXPORTER (default none).
+	shutdownTracing, err := tracing.Setup(context.Background(), "proxy-svc")
+	if err != nil {
+		logger.Fatal("init tracing", zap.Error(err))
+	}
+
+	// ── 3. PostgreSQL (ConfigService, proxy_logs) ─────────────────────────────
 	dbURL := mustEnv("DATABASE_URL")
-	pool, err := pgxpool.New(context.Background(), dbURL)
+	pool, err := tracing.NewPool(context.Background(), dbURL)
 	if err != nil {
 		logger.Fatal("connect postgres", zap.Error(err))
 	}
@@ -62,37 +76,91 @@ func main() {
 	}
 
 	// ── 6. Application components ─────────────────────────────────────────────
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Proxied calls are batched into proxy_logs off the request path.
+	accessLog := accesslog.NewWriter(pool, cfgSvc, logger)
+	accessLog.Start(ctx)
+
 	// NewProxyHandler wires the upstream client and Redis cache internally.
-	upstreamClient := upstream.New(cfgSvc)
-	proxyHandler := handler.NewProxyHandler(upstreamClient, rdbClient, logger)
+	upstreamClient := upstream.New(cfgSvc, rdbClient, logger)
+	upstreamClient.Start(ctx)
+	proxyHandler := handler.NewProxyHandler(upstreamClient, rdbClient, cfgSvc, accessLog, logger)
+	proxyHandler.Start(ctx)
+	// Playback-source chain for /api/song/url; order is overridable via PLAYBACK_SOURCES.
+	// Resolved URLs are cached in Redis until their signed expiry.
+	playbackSources := playback.NewRegistry(cfgSvc, playback.NewURLCache(rdbClient, cfgSvc, logger),
+		playback.NewQQResolver(upstreamClient),
+		playback.NewJooxResolver(upstreamClient, cfgSvc, logger),
+	)
+
+	// Keeps home-screen endpoints warm; only one replica runs each tick.
+	warmCron := proxycron.NewCacheWarmCron(proxyHandler, cfgSvc, rdbClient, logger)
+	if err := warmCron.Start(ctx); err != nil {
+		logger.Warn("cache warm cron start failed (non-fatal)", zap.Error(err))
+	}
 
 	// ── 7. HTTP routes ─────────────────────────────────────────────────────────
 	r := gin.New()
-	r.Use(gin.Recovery())
+	// ClientIP keys the per-IP rate limits, so X-Forwarded-For is only
+	// believed from TRUSTED_PROXIES (comma-separated IPs/CIDRs, default none).
+	if err := r.SetTrustedProxies(envList("TRUSTED_PROXIES")); err != nil {
+		logger.Fatal("parse TRUSTED_PROXIES", zap.Error(err))
+	}
+	r.Use(metrics.Middleware(), tracing.Middleware("proxy-svc"), gin.Recovery())
 	r.GET("/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
 
-	// Reverse proxy for auth-svc: forward /auth/* and /user/* to localhost:8001
-	authURL := envOr("AUTH_SERVICE_URL", "http://localhost:8001")
-	r.Any("/auth/*path", proxyToService(authURL, logger))
-	r.Any("/user/*path", proxyToService(authURL, logger))
+	// Gateway: routes owned by auth-svc and sync-svc are forwarded as-is
+	// (streamed, WebSocket upgrades included) so clients need only this origin.
+	// The backends authenticate these requests themselves.
+	gw := gateway.New(logger)
+	authProxy, err := gw.Proxy("auth-svc", envOr("AUTH_SERVICE_URL", "http://localhost:8001"))
+	if err != nil {
+		logger.Fatal("init gateway", zap.Error(err))
+	}
+	syncProxy, err := gw.Proxy("sync-svc", envOr("SYNC_SERVICE_URL", "http://localhost:8003"))
+	if err != nil {
+		logger.Fatal("init gateway", zap.Error(err))
+	}
+	gateway.Mount(r, "/auth", authProxy)
+	gateway.Mount(r, "/user", authProxy)
+	for _, prefix := range []string{"/api/favorites", "/api/history", "/api/playlists", "/ws"} {
+		gateway.Mount(r, prefix, syncProxy)
+	}
 
-	api := r.Group("/api", proxymw.RequireUser(cfgSvc))
-	{
+	// Per-IP and per-user token buckets per route group; limits come from RATE_LIMITS.
+	limiter := proxymw.NewRateLimiter(rdbClient, cfgSvc, logger)
+	registerAPI := func(api *gin.RouterGroup) {
+		group := func(name string) *gin.RouterGroup {
+			return api.Group("/"+name, limiter.Limit(name))
+		}
 		// Recommend endpoints under /api/recommend/*
-		handler.NewRecommendHandler(proxyHandler).Register(api.Group("/recommend"))
+		handler.NewRecommendHandler(proxyHandler).Register(group("recommend"))
 		// Each resource gets its own sub-group to avoid path conflicts
-		handler.NewPlaylistHandler(proxyHandler).Register(api.Group("/playlist"))
-		handler.NewSingerHandler(proxyHandler).Register(api.Group("/artist"))
-		handler.NewRankingHandler(proxyHandler).Register(api.Group("/ranking"))
-		handler.NewRadioHandler(proxyHandler).Register(api.Group("/radio"))
-		handler.NewMVHandler(proxyHandler).Register(api.Group("/mv"))
-		handler.NewAlbumHandler(proxyHandler).Register(api.Group("/album"))
-		handler.NewSearchHandler(proxyHandler).Register(api.Group("/search"))
-		handler.NewLyricHandler(proxyHandler).Register(api.Group("/lyric"))
-		// Song endpoints (e.g., /api/song/detail?id=...)
-		handler.NewSongHandler(proxyHandler).Register(api.Group("/song"))
+		handler.NewPlaylistHandler(proxyHandler).Register(group("playlist"))
+		handler.NewSingerHandler(proxyHandler).Register(group("artist"))
+		handler.NewRankingHandler(proxyHandler).Register(group("ranking"))
+		handler.NewRadioHandler(proxyHandler).Register(group("radio"))
+		handler.NewMVHandler(proxyHandler).Register(group("mv"))
+		handler.NewAlbumHandler(proxyHandler).Register(group("album"))
+		handler.NewSearchHandler(proxyHandler).Register(group("search"))
+		handler.NewLyricHandler(proxyHandler).Register(group("lyric"))
+		// Song endpoints (e.g., /api/song/detail?id=...); the audio relay is
+		// limited as its own "stream" group.
+		handler.NewSongHandler(proxyHandler, playbackSources).Register(group("song"), api.Group("/song", limiter.Limit("stream")))
 	}
 
+	// Internal router for POST /api/batch items: same handlers, identity
+	// carried over from the already-authenticated batch request.
+	batchRoutes := gin.New()
+	batchRoutes.Use(gin.Recovery())
+	registerAPI(batchRoutes.Group("/api", proxymw.TrustedIdentity()))
+
+	api := r.Group("/api", proxymw.RequireUser(cfgSvc))
+	registerAPI(api)
+	handler.NewBatchHandler(proxyHandler, batchRoutes).Register(api.Group("/batch", limiter.Limit("batch")))
+
 	// ── 8. Serve ───────────────────────────────────────────────────────────────
 	addr := envOr("LISTEN_ADDR", ":8002")
 	srv := &http.Server{Addr: addr, Handler: r}
@@ -104,16 +172,33 @@ func main() {
 		}
 	}()
 
+	// Prometheus scrape endpoint on its own listener, kept off the public port.
+	metricsSrv := metrics.NewServer(envOr("METRICS_ADDR", ":9002"))
+	go func() {
+		logger.Info("proxy-svc metrics listening", zap.String("addr", metricsSrv.Addr))
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server error", zap.Error(err))
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("shutting down proxy-svc")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
+	cancel() // stop background work
+	warmCron.Stop()
+
+	shutCtx, shutCancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer shutCancel()
+	if err := srv.Shutdown(shutCtx); err != nil {
 		logger.Error("graceful shutdown failed", zap.Error(err))
 	}
+	_ = metricsSrv.Shutdown(shutCtx)
+	_ = shutdownTracing(shutCtx)
+	proxyHandler.Close()
+	gw.Close()
+	accessLog.Close() // flush after in-flight requests have been logged
 }
 
 func mustEnv(key string) string {
@@ -124,71 +209,20 @@ func mustEnv(key string) string {
 	return v
 }
 
-func envOr(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
-	}
-	return def
-}
-
-// proxyToService creates a reverse proxy handler that forwards requests to a backend service.
-func proxyToService(targetURL string, logger *zap.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Build target URL: targetURL + original path + query
-		path := c.Param("path")
-		fullPath := c.Request.URL.Path[:len(c.Request.URL.Path)-len(path)] + path
-		targetReq := targetURL + fullPath
-		if c.Request.URL.RawQuery != "" {
-			targetReq += "?" + c.Request.URL.RawQuery
-		}
-
-		// Create proxy request
-		req, err := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, targetReq, c.Request.Body)
-		if err != nil {
-			logger.Error("proxy: create request", zap.Error(err))
-			c.JSON(http.StatusBadGateway, gin.H{"code": "PROXY_ERROR"})
-			return
-		}
-
-		// Copy headers
-		for k, vv := range c.Request.Header {
-			for _, v := range vv {
-				req.Header.Add(k, v)
-			}
-		}
-
-		// Forward request
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Do(req)
-		if err != nil {
-			logger.Error("proxy: forward request", zap.Error(err), zap.String("target", targetReq))
-			c.JSON(http.StatusBadGateway, gin.H{"code": "SERVICE_UNAVAILABLE"})
-			return
+// envList splits a comma-separated env var, dropping empty entries.
+func envList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
 		}
-		defer resp.Body.Close()
-
-		// Copy response headers
-		for k, vv := range resp.Header {
-			for _, v := range vv {
-				c.Writer.Header().Add(k, v)
-			}
-		}
-
-		// Copy response status and body
-		c.Status(resp.StatusCode)
-		_, _ = c.Writer.Write(mustReadAll(resp.Body))
 	}
+	return out
 }
 
-func mustReadAll(r interface{ Read([]byte) (int, error) }) []byte {
-	buf := make([]byte, 0, 4096)
-	tmp := make([]byte, 1024)
-	for {
-		n, err := r.Read(tmp)
-		buf = append(buf, tmp[:n]...)
-		if err != nil {
-			break
-		}
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
-	return buf
+	return def
 }
@@ -0,0 +1,207 @@
+// Package accesslog records proxied requests into the partitioned proxy_logs
+// table without putting PostgreSQL on the request path.
+package accesslog
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/config"
+)
+
+const (
+	// bufferSize is how many records may be queued before Log starts dropping.
+	bufferSize = 4096
+	// batchSize flushes as soon as this many records are pending.
+	batchSize = 500
+	// flushInterval flushes a partial batch at least this often.
+	flushInterval = 2 * time.Second
+	// flushTimeout bounds one COPY.
+	flushTimeout = 10 * time.Second
+
+	// maintainInterval is how often partitions are pre-created and pruned.
+	maintainInterval = time.Hour
+	// partitionsAhead is how many daily partitions are kept ready in advance.
+	partitionsAhead = 3
+
+	// cfgRetentionDays is how many days of proxy_logs are kept; "0" disables pruning.
+	cfgRetentionDays     = "PROXY_LOG_RETENTION_DAYS"
+	defaultRetentionDays = 30
+)
+
+var columns = []string{
+	"created_at", "user_id", "path", "query_hash", "cache_status", "upstream", "status", "latency_ms",
+}
+
+// Record is one proxied request.
+type Record struct {
+	Time        time.Time
+	UserID      string // empty for internal callers
+	Path        string // upstream path, e.g. "/recommend/banner"
+	QueryHash   string // same hash as the cache key
+	CacheStatus string // X-Cache value, or "ERROR"
+	Upstream    string // base URL that served a MISS; empty otherwise
+	Status      int
+	Latency     time.Duration
+}
+
+// Writer buffers records in memory and writes them in batches with COPY.
+// Log never blocks: when the buffer is full the record is dropped and counted,
+// so a slow or unavailable database cannot slow down the proxy.
+type Writer struct {
+	pool   *pgxpool.Pool
+	cfgSvc config.Service
+	log    *zap.Logger
+
+	records chan Record
+	dropped atomic.Int64
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewWriter creates a Writer. Call Start to begin flushing and Close to drain.
+func NewWriter(pool *pgxpool.Pool, cfgSvc config.Service, log *zap.Logger) *Writer {
+	return &Writer{
+		pool:    pool,
+		cfgSvc:  cfgSvc,
+		log:     log,
+		records: make(chan Record, bufferSize),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start launches the flush loop and the partition maintenance loop.
+func (w *Writer) Start(ctx context.Context) {
+	w.wg.Add(2)
+	go w.flushLoop()
+	go w.maintainLoop(ctx)
+}
+
+// Log queues r for writing. It is safe to call from any goroutine.
+func (w *Writer) Log(r Record) {
+	select {
+	case w.records <- r:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// Close stops both loops and flushes everything still buffered.
+func (w *Writer) Close() {
+	close(w.stop)
+	w.wg.Wait()
+}
+
+// ── internals ────────────────────────────────────────────────────────────────
+
+func (w *Writer) flushLoop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, batchSize)
+	for {
+		select {
+		case r := <-w.records:
+			batch = append(batch, r)
+			if len(batch) >= batchSize {
+				batch = w.flush(batch)
+			}
+		case <-ticker.C:
+			batch = w.flush(batch)
+		case <-w.stop:
+			for {
+				select {
+				case r := <-w.records:
+					batch = append(batch, r)
+				default:
+					w.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes batch and returns it emptied for reuse. A failed batch is
+// dropped: access logs are best-effort and retrying would grow memory.
+func (w *Writer) flush(batch []Record) []Record {
+	if n := w.dropped.Swap(0); n > 0 {
+		w.log.Warn("proxy log buffer full, records dropped", zap.Int64("dropped", n))
+	}
+	if len(batch) == 0 {
+		return batch
+	}
+	rows := make([][]any, len(batch))
+	for i, r := range batch {
+		var userID *string
+		if r.UserID != "" {
+			userID = &batch[i].UserID
+		}
+		rows[i] = []any{
+			r.Time, userID, r.Path, r.QueryHash, r.CacheStatus, r.Upstream,
+			int32(r.Status), int32(r.Latency.Milliseconds()),
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if _, err := w.pool.CopyFrom(ctx, pgx.Identifier{"proxy_logs"}, columns, pgx.CopyFromRows(rows)); err != nil {
+		w.log.Warn("proxy log flush failed", zap.Int("records", len(batch)), zap.Error(err))
+	}
+	return batch[:0]
+}
+
+func (w *Writer) maintainLoop(ctx context.Context) {
+	defer w.wg.Done()
+	w.maintain(ctx)
+	ticker := time.NewTicker(maintainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.maintain(ctx)
+		case <-w.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// maintain pre-creates upcoming daily partitions and drops those older than
+// PROXY_LOG_RETENTION_DAYS. Both functions are idempotent, so every replica
+// may run them.
+func (w *Writer) maintain(ctx context.Context) {
+	if _, err := w.pool.Exec(ctx, "SELECT ensure_proxy_logs_partitions($1)", partitionsAhead); err != nil {
+		w.log.Warn("proxy log: ensure partitions failed", zap.Error(err))
+	}
+
+	days := defaultRetentionDays
+	if v, err := w.cfgSvc.Get(ctx, cfgRetentionDays); err == nil && v != "" {
+		if n, perr := strconv.Atoi(v); perr == nil && n >= 0 {
+			days = n
+		}
+	}
+	if days == 0 {
+		return
+	}
+	// Sent as a date string: a time.Time would be cast to date in the
+	// session time zone, not UTC like the partitions.
+	cutoff := time.Now().UTC().AddDate(0, 0, -days).Format(time.DateOnly)
+	var dropped int
+	if err := w.pool.QueryRow(ctx, "SELECT drop_proxy_logs_before($1::date)", cutoff).Scan(&dropped); err != nil {
+		w.log.Warn("proxy log: prune failed", zap.Error(err))
+		return
+	}
+	if dropped > 0 {
+		w.log.Info("proxy log partitions pruned", zap.Int("dropped", dropped), zap.Int("retention_days", days))
+	}
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Entries are stored in Redis as a binary envelope rather than JSON, which
+// would base64-inflate the body by a third:
+//
+//	magic "LSE1" | flags u8 | stored_at i64 | etag_len u16 | etag |
+//	upstream_len u16 | upstream | payload
+//
+// All integers are big-endian. With flagGzip set the payload is the gzip
+// member served verbatim to clients that accept gzip.
+const (
+	envelopeMagic = "LSE1"
+	flagGzip      = 1 << 0
+
+	// minGzipSize is the smallest body worth compressing; below it the gzip
+	// header and trailer outweigh the savings.
+	minGzipSize = 512
+)
+
+var errBadEnvelope = errors.New("cache: malformed envelope")
+
+// encode serialises e, compressing the body (once) if it is large enough.
+func encode(e *Entry) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.gz == nil && len(e.body) >= minGzipSize {
+		gz, err := compress(e.body)
+		if err != nil {
+			return nil, err
+		}
+		e.gz = gz
+	}
+	payload, flags := e.body, byte(0)
+	if e.gz != nil {
+		payload, flags = e.gz, flagGzip
+	}
+	if len(e.ETag) > 0xffff || len(e.Upstream) > 0xffff {
+		return nil, fmt.Errorf("cache: header field too long")
+	}
+
+	buf := make([]byte, 0, len(envelopeMagic)+1+8+2+len(e.ETag)+2+len(e.Upstream)+len(payload))
+	buf = append(buf, envelopeMagic...)
+	buf = append(buf, flags)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(e.StoredAt))
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(e.ETag)))
+	buf = append(buf, e.ETag...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(e.Upstream)))
+	buf = append(buf, e.Upstream...)
+	return append(buf, payload...), nil
+}
+
+// decode parses an envelope. The payload is kept as stored: a gzip body is
+// only decompressed if Entry.Body is called. Entries written as JSON by
+// earlier versions are still accepted until they expire.
+func decode(raw []byte) (*Entry, error) {
+	if len(raw) > 0 && raw[0] == '{' {
+		var legacy struct {
+			Body     []byte `json:"body"`
+			ETag     string `json:"etag"`
+			StoredAt int64  `json:"stored_at"`
+			Upstream string `json:"upstream"`
+		}
+		if err := json.Unmarshal(raw, &legacy); err != nil {
+			return nil, fmt.Errorf("cache: unmarshal: %w", err)
+		}
+		e := NewEntry(legacy.Body, legacy.ETag, legacy.Upstream)
+		e.StoredAt = legacy.StoredAt
+		return e, nil
+	}
+
+	r := raw
+	if len(r) < len(envelopeMagic)+1+8 || string(r[:len(envelopeMagic)]) != envelopeMagic {
+		return nil, errBadEnvelope
+	}
+	r = r[len(envelopeMagic):]
+	flags := r[0]
+	e := &Entry{StoredAt: int64(binary.BigEndian.Uint64(r[1:9]))}
+	r = r[9:]
+	var ok bool
+	if e.ETag, r, ok = readString(r); !ok {
+		return nil, errBadEnvelope
+	}
+	if e.Upstream, r, ok = readString(r); !ok {
+		return nil, errBadEnvelope
+	}
+	if flags&flagGzip != 0 {
+		e.gz = r
+	} else {
+		e.body = r
+	}
+	return e, nil
+}
+
+func readString(r []byte) (string, []byte, bool) {
+	if len(r) < 2 {
+		return "", nil, false
+	}
+	n := int(binary.BigEndian.Uint16(r))
+	r = r[2:]
+	if len(r) < n {
+		return "", nil, false
+	}
+	return string(r[:n]), r[n:], true
+}
+
+func compress(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(body) / 4)
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(gz []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"listen-stream/shared/pkg/rdb"
+)
+
+const (
+	// fillLockTTL bounds how long a crashed lock holder can block other
+	// replicas. It covers the worst case of primary + fallback upstream
+	// attempts (2 × 10 s client timeout).
+	fillLockTTL = 25 * time.Second
+	// fillPollInterval is how often a waiting replica re-checks the cache.
+	fillPollInterval = 100 * time.Millisecond
+)
+
+// AcquireFill tries to take the cross-replica fill lock for key.
+//
+// acquired == true means the caller owns the lock and must call release once
+// the cache has been written (or the upstream call failed). acquired == false
+// means another replica is already fetching key; the caller should WaitFill.
+//
+// Redis errors are treated as "acquired" with a no-op release: coalescing is
+// an optimisation and must never block a request on its own.
+func (c *ProxyCache) AcquireFill(ctx context.Context, key string) (release func(), acquired bool) {
+	token, err := randomToken()
+	if err != nil {
+		return func() {}, true
+	}
+	lockKey := rdb.KeyProxyFillLock(key)
+	ok, err := c.rdb.SetNX(ctx, lockKey, token, fillLockTTL)
+	if err != nil {
+		return func() {}, true
+	}
+	if !ok {
+		return func() {}, false
+	}
+	return func() {
+		// Detached context: release must still run if the request was cancelled.
+		_, _ = c.rdb.DelIfEqual(context.WithoutCancel(ctx), lockKey, token)
+	}, true
+}
+
+// WaitFill polls the live cache key until another replica fills it, the
+// fill lock disappears, or timeout elapses. It returns nil if no entry
+// appeared; the caller should then fetch upstream itself.
+func (c *ProxyCache) WaitFill(ctx context.Context, key string, timeout time.Duration) *Entry {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	lockKey := rdb.KeyProxyFillLock(key)
+	ticker := time.NewTicker(fillPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if e, err := c.Get(ctx, key); err == nil && e != nil {
+			return e
+		}
+		// Lock gone: either the holder filled and released between our two
+		// reads (re-check once), or it failed and the caller must fetch.
+		if _, err := c.rdb.Get(ctx, lockKey); err != nil {
+			if e, err := c.Get(ctx, key); err == nil && e != nil {
+				return e
+			}
+			return nil
+		}
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
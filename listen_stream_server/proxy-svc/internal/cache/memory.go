@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryTier is a size-bounded LRU of encoded envelopes, checked before
+// Redis. It holds the exact bytes stored in Redis, so a memory hit skips the
+// round trip, and gzip clients are served the stored bytes without any copy.
+//
+// Each item expires with the Redis key it mirrors. Replicas keep their tiers
+// coherent by evicting keys named in invalidation messages (see ProxyCache.Run).
+type memoryTier struct {
+	maxBytes int64
+
+	mu    sync.Mutex
+	bytes int64
+	order *list.List               // front = most recently used
+	items map[string]*list.Element // key → element holding *memItem
+}
+
+type memItem struct {
+	key     string
+	raw     []byte
+	expires time.Time
+}
+
+func newMemoryTier(maxBytes int64) *memoryTier {
+	return &memoryTier{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// get returns the envelope for key, or nil if absent or expired.
+func (m *memoryTier) get(key string) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return nil
+	}
+	it := el.Value.(*memItem)
+	if time.Now().After(it.expires) {
+		m.remove(el)
+		return nil
+	}
+	m.order.MoveToFront(el)
+	return it.raw
+}
+
+// put stores raw for key until ttl elapses, evicting least recently used
+// items to stay within maxBytes. Items larger than 1/8 of the tier are not
+// kept so one huge payload cannot flush the hot set.
+func (m *memoryTier) put(key string, raw []byte, ttl time.Duration) {
+	size := int64(len(raw) + len(key))
+	if ttl <= 0 || size > m.maxBytes/8 {
+		m.evict(key)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+	m.items[key] = m.order.PushFront(&memItem{key: key, raw: raw, expires: time.Now().Add(ttl)})
+	m.bytes += size
+	for m.bytes > m.maxBytes {
+		m.remove(m.order.Back())
+	}
+}
+
+// evict removes key if present.
+func (m *memoryTier) evict(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+}
+
+// evictPrefix removes every key starting with prefix.
+func (m *memoryTier) evictPrefix(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, el := range m.items {
+		if strings.HasPrefix(key, prefix) {
+			m.remove(el)
+		}
+	}
+}
+
+// remove unlinks el; m.mu must be held.
+func (m *memoryTier) remove(el *list.Element) {
+	it := m.order.Remove(el).(*memItem)
+	delete(m.items, it.key)
+	m.bytes -= int64(len(it.raw) + len(it.key))
+}
@@ -5,28 +5,143 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"go.uber.org/zap"
+
 	"listen-stream/shared/pkg/rdb"
 )
 
+// Tiers an Entry can be served from.
+const (
+	TierMemory   = "memory"
+	TierRedis    = "redis"
+	TierUpstream = "upstream" // freshly fetched; not read from the cache
+)
+
 // Entry holds a cached HTTP response body and its ETag.
+//
+// The body is held plain, gzip-compressed, or both: entries read from Redis
+// carry only what was stored, and the other form is derived on first use.
+// An Entry may be shared by coalesced requests; its methods are safe for
+// concurrent use.
 type Entry struct {
-	Body []byte `json:"body"`
-	ETag string `json:"etag"`
+	ETag string
+	// StoredAt is the Unix time (seconds) the entry was written; Set fills it in.
+	StoredAt int64
+	// Upstream is the base URL the body was fetched from (for proxy_logs).
+	Upstream string
+	// Tier is where this Entry was read from (TierMemory, TierRedis), or
+	// TierUpstream for a fresh fetch.
+	Tier string
+
+	mu   sync.Mutex
+	body []byte // plain body; nil until decompressed
+	gz   []byte // gzip member; nil if stored uncompressed or not yet written
+}
+
+// NewEntry creates an Entry for a freshly fetched upstream body.
+func NewEntry(body []byte, etag, upstream string) *Entry {
+	return &Entry{ETag: etag, Upstream: upstream, Tier: TierUpstream, body: body}
+}
+
+// Body returns the uncompressed body.
+func (e *Entry) Body() ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.body == nil && e.gz != nil {
+		body, err := decompress(e.gz)
+		if err != nil {
+			return nil, fmt.Errorf("cache: decompress: %w", err)
+		}
+		e.body = body
+	}
+	return e.body, nil
+}
+
+// Gzip returns the body as a gzip member ready to send with
+// Content-Encoding: gzip, or nil if the entry is stored uncompressed.
+func (e *Entry) Gzip() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.gz
+}
+
+// Age returns how long ago the entry was written.
+func (e *Entry) Age() time.Duration {
+	return time.Since(time.Unix(e.StoredAt, 0))
 }
 
 // ProxyCache stores and retrieves serialised HTTP responses in Redis.
 // For every cached path it writes two Redis keys:
-//   - live key  (TTL == requested ttl)  — served on normal hits
-//   - stale key (TTL == 2 × ttl)        — served when upstream is unavailable
+//   - live key  (TTL == requested ttl)  — served on normal hits, and while
+//     revalidating in the background once past the soft TTL
+//   - stale key (TTL == 2 × ttl)        — served only when upstream is unavailable
+//
+// When built with a memory budget, reads first check an in-process LRU tier
+// mirroring recently used Redis keys. Every write is broadcast on
+// rdb.KeyProxyInvalidateChannel so other replicas drop their copy; Run
+// applies those broadcasts (and admin-svc purges) locally.
 type ProxyCache struct {
-	rdb *rdb.Client
+	rdb    *rdb.Client
+	memory *memoryTier // nil when the in-process tier is disabled
+	origin string      // identifies this replica's own broadcasts
+	log    *zap.Logger
+}
+
+// NewProxyCache creates a ProxyCache backed by the given Redis client with an
+// in-process tier of at most memoryBytes (0 disables it).
+func NewProxyCache(rdbClient *rdb.Client, memoryBytes int64, log *zap.Logger) *ProxyCache {
+	origin, _ := randomToken()
+	c := &ProxyCache{rdb: rdbClient, origin: origin, log: log}
+	if memoryBytes > 0 {
+		c.memory = newMemoryTier(memoryBytes)
+	}
+	return c
 }
 
-// NewProxyCache creates a ProxyCache backed by the given Redis client.
-func NewProxyCache(rdbClient *rdb.Client) *ProxyCache {
-	return &ProxyCache{rdb: rdbClient}
+// invalidation is the payload on rdb.KeyProxyInvalidateChannel.
+type invalidation struct {
+	Origin string `json:"origin"`
+	Key    string `json:"key,omitempty"`    // a live key; its stale copy goes too
+	Prefix string `json:"prefix,omitempty"` // an upstream path prefix
+}
+
+// Run applies invalidations broadcast by other replicas and admin-svc to the
+// in-process tier. Blocks until ctx is cancelled; a no-op without a tier.
+func (c *ProxyCache) Run(ctx context.Context) {
+	if c.memory == nil {
+		return
+	}
+	pubsub := c.rdb.Subscribe(ctx, rdb.KeyProxyInvalidateChannel())
+	defer pubsub.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-pubsub.Channel():
+			if !ok {
+				return
+			}
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				c.log.Warn("cache: invalid invalidation payload", zap.Error(err))
+				continue
+			}
+			if inv.Origin == c.origin {
+				continue
+			}
+			if inv.Key != "" {
+				c.memory.evict(inv.Key)
+				c.memory.evict(staleKey(inv.Key))
+			}
+			if inv.Prefix != "" {
+				c.memory.evictPrefix(strings.TrimSuffix(rdb.KeyProxyCache(inv.Prefix, ""), ":"))
+			}
+		}
+	}
 }
 
 // Get returns the live cached entry for key, or an error on miss / unmarshal failure.
@@ -47,9 +162,12 @@ func (c *ProxyCache) Set(ctx context.Context, key string, e *Entry, ttl time.Dur
 	if ttl == 0 {
 		return nil
 	}
-	data, err := json.Marshal(e)
+	if e.StoredAt == 0 {
+		e.StoredAt = time.Now().Unix()
+	}
+	data, err := encode(e)
 	if err != nil {
-		return fmt.Errorf("cache: marshal: %w", err)
+		return fmt.Errorf("cache: encode: %w", err)
 	}
 	val := string(data)
 	// Live key
@@ -58,21 +176,59 @@ func (c *ProxyCache) Set(ctx context.Context, key string, e *Entry, ttl time.Dur
 	}
 	// Stale backup — ignore errors (best effort)
 	_ = c.rdb.Set(ctx, staleKey(key), val, ttl*2)
+
+	if c.memory != nil {
+		c.memory.put(key, data, ttl)
+		c.memory.put(staleKey(key), data, ttl*2)
+		// Other replicas re-read the new entry from Redis on their next hit.
+		if msg, err := json.Marshal(invalidation{Origin: c.origin, Key: key}); err == nil {
+			if err := c.rdb.Publish(ctx, rdb.KeyProxyInvalidateChannel(), string(msg)); err != nil {
+				c.log.Warn("cache: publish invalidation failed", zap.Error(err))
+			}
+		}
+	}
 	return nil
 }
 
 // ── helpers ──────────────────────────────────────────────────────────────────
 
+// load reads key from the in-process tier, falling back to Redis. A Redis hit
+// is copied into the tier until the Redis key itself expires.
 func (c *ProxyCache) load(ctx context.Context, key string) (*Entry, error) {
-	raw, err := c.rdb.Get(ctx, key)
+	if c.memory != nil {
+		if raw := c.memory.get(key); raw != nil {
+			e, err := decode(raw)
+			if err == nil {
+				e.Tier = TierMemory
+				return e, nil
+			}
+			c.memory.evict(key)
+		}
+	}
+
+	var (
+		raw string
+		ttl time.Duration
+		err error
+	)
+	if c.memory != nil {
+		raw, ttl, err = c.rdb.GetTTL(ctx, key)
+	} else {
+		raw, err = c.rdb.Get(ctx, key)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("cache miss: %w", err)
 	}
-	var e Entry
-	if err := json.Unmarshal([]byte(raw), &e); err != nil {
-		return nil, fmt.Errorf("cache: unmarshal: %w", err)
+	data := []byte(raw)
+	e, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	e.Tier = TierRedis
+	if c.memory != nil && ttl > 0 {
+		c.memory.put(key, data, ttl)
 	}
-	return &e, nil
+	return e, nil
 }
 
 func staleKey(key string) string {
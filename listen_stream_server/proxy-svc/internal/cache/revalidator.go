@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Revalidator refreshes soft-expired cache entries in the background with a
+// fixed number of workers. Submissions are deduplicated per key and dropped
+// (not blocked) when the queue is full: the stale copy keeps being served and
+// a later request will resubmit.
+type Revalidator struct {
+	tasks chan revalidateTask
+	log   *zap.Logger
+
+	mu      sync.Mutex
+	pending map[string]struct{} // keys queued or running; protected by mu
+}
+
+type revalidateTask struct {
+	key string
+	fn  func(ctx context.Context)
+}
+
+// NewRevalidator starts workers goroutines draining a queue of size queueSize.
+func NewRevalidator(workers, queueSize int, log *zap.Logger) *Revalidator {
+	r := &Revalidator{
+		tasks:   make(chan revalidateTask, queueSize),
+		log:     log,
+		pending: make(map[string]struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		go r.work()
+	}
+	return r
+}
+
+// Submit queues fn to refresh key. It returns false if key is already queued
+// or running, or if the queue is full.
+func (r *Revalidator) Submit(key string, fn func(ctx context.Context)) bool {
+	r.mu.Lock()
+	if _, busy := r.pending[key]; busy {
+		r.mu.Unlock()
+		return false
+	}
+	r.pending[key] = struct{}{}
+	r.mu.Unlock()
+
+	select {
+	case r.tasks <- revalidateTask{key: key, fn: fn}:
+		return true
+	default:
+		r.done(key)
+		r.log.Warn("revalidate queue full, dropping refresh", zap.String("key", key))
+		return false
+	}
+}
+
+func (r *Revalidator) work() {
+	for t := range r.tasks {
+		t.fn(context.Background())
+		r.done(t.key)
+	}
+}
+
+func (r *Revalidator) done(key string) {
+	r.mu.Lock()
+	delete(r.pending, key)
+	r.mu.Unlock()
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/rdb"
+)
+
+const (
+	// statsFlushInterval is how often in-process counts are added to Redis.
+	statsFlushInterval = 10 * time.Second
+	// statsRetention is how long each hourly bucket is kept.
+	statsRetention = 48 * time.Hour
+)
+
+// Stats counts cache outcomes (X-Cache status) per upstream path and the
+// tier each response was served from, and adds them to hourly Redis hashes
+// (rdb.KeyProxyStats, rdb.KeyProxyTierStats) every statsFlushInterval, so the
+// request path never waits on a counter write. admin-svc derives hit / miss /
+// stale ratios and per-tier hit rates from the hashes.
+type Stats struct {
+	rdb *rdb.Client
+	log *zap.Logger
+
+	mu     sync.Mutex
+	counts map[string]int64 // "<path>|<status>" → count; protected by mu
+	tiers  map[string]int64 // tier → count; protected by mu
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewStats creates Stats and starts its flush loop. Call Close to stop it.
+func NewStats(rdbClient *rdb.Client, log *zap.Logger) *Stats {
+	s := &Stats{
+		rdb:    rdbClient,
+		log:    log,
+		counts: make(map[string]int64),
+		tiers:  make(map[string]int64),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+// Count records one request for upstreamPath with X-Cache status.
+func (s *Stats) Count(upstreamPath, status string) {
+	s.mu.Lock()
+	s.counts[upstreamPath+"|"+status]++
+	s.mu.Unlock()
+}
+
+// CountTier records one response served from tier (TierMemory, TierRedis
+// or TierUpstream).
+func (s *Stats) CountTier(tier string) {
+	s.mu.Lock()
+	s.tiers[tier]++
+	s.mu.Unlock()
+}
+
+// Close stops the flush loop after a final flush.
+func (s *Stats) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Stats) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(statsFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush moves the pending counts into the current hour's bucket. Counts are
+// lost if Redis is unavailable; ratios tolerate the gap.
+func (s *Stats) flush() {
+	s.mu.Lock()
+	counts, tiers := s.counts, s.tiers
+	s.counts = make(map[string]int64, len(counts))
+	s.tiers = make(map[string]int64, len(tiers))
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	hour := time.Now().UTC().Format("2006010215")
+	if len(counts) > 0 {
+		if err := s.rdb.HIncrByMany(ctx, rdb.KeyProxyStats(hour), counts, statsRetention); err != nil {
+			s.log.Warn("proxy stats flush failed", zap.Error(err))
+		}
+	}
+	if len(tiers) > 0 {
+		if err := s.rdb.HIncrByMany(ctx, rdb.KeyProxyTierStats(hour), tiers, statsRetention); err != nil {
+			s.log.Warn("proxy tier stats flush failed", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package catalog defines the stable, upstream-independent music schema served
+// by proxy-svc in normalized mode (?format=normalized), plus the translators
+// that map each upstream's raw JSON into it.
+//
+// Clients that opt in never see upstream quirks: identifiers are always the
+// upstream "mid" strings (never numeric ids), durations are seconds, dates are
+// YYYY-MM-DD, and the upstream {"code": 1, "data": …} envelope is unwrapped.
+package catalog
+
+import (
+	"errors"
+	"net/url"
+)
+
+// FormatNormalized is the ?format= value that selects the catalog schema.
+const FormatNormalized = "normalized"
+
+// ErrUnsupported is returned by Translate for upstream paths that have no
+// normalized representation (categories, banners, hot keys, …).
+var ErrUnsupported = errors.New("catalog: no normalized form for this endpoint")
+
+// ErrUpstreamCode is returned when the upstream envelope reports failure
+// (code != 1).
+var ErrUpstreamCode = errors.New("catalog: upstream reported failure")
+
+// Artist is a singer or band.
+type Artist struct {
+	MID        string `json:"mid"`
+	Name       string `json:"name"`
+	AltName    string `json:"alt_name,omitempty"`
+	AvatarURL  string `json:"avatar_url,omitempty"`
+	Brief      string `json:"brief,omitempty"`
+	SongCount  int    `json:"song_count,omitempty"`
+	AlbumCount int    `json:"album_count,omitempty"`
+	MVCount    int    `json:"mv_count,omitempty"`
+}
+
+// Album is a release. When embedded in a Song only MID, Name and CoverURL are set.
+type Album struct {
+	MID         string   `json:"mid"`
+	Name        string   `json:"name"`
+	CoverURL    string   `json:"cover_url,omitempty"`
+	Artists     []Artist `json:"artists,omitempty"`
+	ReleaseDate string   `json:"release_date,omitempty"`
+	Description string   `json:"description,omitempty"`
+	TrackCount  int      `json:"track_count,omitempty"`
+	Company     string   `json:"company,omitempty"`
+}
+
+// Song is a playable track. MID is the key for /api/song/url and /api/lyric.
+// MID may be empty for chart entries that the upstream only identifies by numeric id.
+type Song struct {
+	MID         string   `json:"mid"`
+	Name        string   `json:"name"`
+	Subtitle    string   `json:"subtitle,omitempty"`
+	Artists     []Artist `json:"artists"`
+	Album       *Album   `json:"album,omitempty"`
+	DurationSec int      `json:"duration_sec,omitempty"`
+	MVVID       string   `json:"mv_vid,omitempty"`
+	ReleaseDate string   `json:"release_date,omitempty"`
+	// Rank is the 1-based chart position; only set for ranking entries.
+	Rank int `json:"rank,omitempty"`
+}
+
+// Playlist is a user, editorial or chart song list.
+type Playlist struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	CoverURL    string `json:"cover_url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Creator     string `json:"creator,omitempty"`
+	PlayCount   int64  `json:"play_count,omitempty"`
+	SongCount   int    `json:"song_count,omitempty"`
+	Songs       []Song `json:"songs,omitempty"`
+}
+
+// MV is a music video. VID is the key for /api/mv/detail.
+type MV struct {
+	VID         string   `json:"vid"`
+	Name        string   `json:"name"`
+	CoverURL    string   `json:"cover_url,omitempty"`
+	Artists     []Artist `json:"artists"`
+	DurationSec int      `json:"duration_sec,omitempty"`
+	PlayCount   int64    `json:"play_count,omitempty"`
+	ReleaseDate string   `json:"release_date,omitempty"`
+}
+
+// Lyric holds raw LRC text for a song and its optional translation.
+type Lyric struct {
+	LRC         string `json:"lrc"`
+	Translation string `json:"translation,omitempty"`
+}
+
+// List is a page of items. Total is the upstream's overall count when known,
+// otherwise len(Items).
+type List[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+}
+
+// ArtistDetail is an artist with their top songs.
+type ArtistDetail struct {
+	Artist
+	Songs []Song `json:"songs"`
+}
+
+// translator maps a raw upstream body to a catalog value.
+// q is the upstream query (after param remapping), e.g. search "type".
+type translator func(q url.Values, body []byte) (interface{}, error)
+
+// Supported reports whether upstreamPath has a normalized form.
+func Supported(upstreamPath string) bool {
+	_, ok := qqTranslators[upstreamPath]
+	return ok
+}
+
+// Translate converts the raw body of upstreamPath into its catalog form.
+// rawQuery is the query string that was sent upstream.
+// Returns ErrUnsupported when the endpoint has no normalized form.
+func Translate(upstreamPath, rawQuery string, body []byte) (interface{}, error) {
+	t, ok := qqTranslators[upstreamPath]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	q, _ := url.ParseQuery(rawQuery)
+	return t(q, body)
+}
@@ -0,0 +1,250 @@
+package catalog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fixtureDir holds the upstream payloads captured in the repo's api/ folder.
+const fixtureDir = "../../../../api"
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join(fixtureDir, name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return body
+}
+
+// wantSong is the subset of a Song the fixture tests pin down.
+type wantSong struct {
+	mid, name           string
+	artists             []string
+	albumMID, albumName string
+	duration            int
+}
+
+func checkSong(t *testing.T, got Song, want wantSong) {
+	t.Helper()
+	if got.MID != want.mid || got.Name != want.name {
+		t.Errorf("song = %q %q, want %q %q", got.MID, got.Name, want.mid, want.name)
+	}
+	if names := artistNames(got.Artists); !reflect.DeepEqual(names, want.artists) {
+		t.Errorf("artists = %q, want %q", names, want.artists)
+	}
+	if got.Album == nil {
+		t.Errorf("album = nil, want %q %q", want.albumMID, want.albumName)
+	} else if got.Album.MID != want.albumMID || got.Album.Name != want.albumName {
+		t.Errorf("album = %q %q, want %q %q", got.Album.MID, got.Album.Name, want.albumMID, want.albumName)
+	}
+	if got.DurationSec != want.duration {
+		t.Errorf("duration = %d, want %d", got.DurationSec, want.duration)
+	}
+}
+
+func artistNames(as []Artist) []string {
+	names := make([]string, len(as))
+	for i, a := range as {
+		names[i] = a.Name
+	}
+	return names
+}
+
+// songsOf returns the songs carried by a translated value.
+func songsOf(t *testing.T, v interface{}) []Song {
+	t.Helper()
+	switch v := v.(type) {
+	case Song:
+		return []Song{v}
+	case List[Song]:
+		return v.Items
+	case Playlist:
+		return v.Songs
+	case ArtistDetail:
+		return v.Songs
+	}
+	t.Fatalf("translated to %T, which carries no songs", v)
+	return nil
+}
+
+func TestTranslateSongFixtures(t *testing.T) {
+	jay := []string{"周杰伦"}
+	sunny := wantSong{"0039MnYb0qxYhV", "晴天", jay, "000MkMni19ClKG", "叶惠美", 269}
+	daily := wantSong{"000WFRAn2vFSv7", "天亮了", []string{"韩红"}, "0037wELT3srhxc", "醒了", 222}
+
+	tests := []struct {
+		path, query, fixture string
+		count                int
+		first                wantSong
+	}{
+		{"/song/detail", "", "song_detail.json", 1,
+			wantSong{"003FYnmA2KmMXg", "接财运", []string{"周深"}, "003Iw29j2lAefm", "接财运", 168}},
+		{"/album/songs", "", "album_songs.json", 1,
+			wantSong{"002yIFEJ0ssJjj", "恒星不忘 Forever Forever", []string{"周杰伦", "言承旭", "吴建豪", "周渝民", "五月天 阿信"}, "004Bux530GytNj", "恒星不忘 Forever Forever", 287}},
+		{"/artist/detail", "", "singer_detail.json", 30, sunny},
+		{"/artist/songs", "", "singer_songs.json", 20, sunny},
+		{"/recommend/new/songs", "", "recommend_new_songs.json", 73,
+			wantSong{"000s3vm413GsbX", "夕阳之歌", []string{"于适"}, "000nF2s60Vp4Lw", "适诗+", 303}},
+		{"/recommend/daily", "", "recommend_daily.json", 30, daily},
+		{"/playlist/detail", "", "playlist_detail.json", 30, daily},
+		{"/radio/songlist", "", "radio_songs.json", 5,
+			wantSong{"0013cyOw3G4CBB", "第三个吻痕", []string{"李思甜"}, "002idPHl114h4a", "第三个吻痕", 221}},
+		// Chart entries only carry a numeric id, no mid, duration or album name.
+		{"/rankings/detail", "", "ranking_detail.json", 100,
+			wantSong{"", "别怕变老", []string{"王以太/艾热AIR"}, "000KoPeI484qGg", "", 0}},
+		{"/search/", "type=0", "search_song.json", 20, sunny},
+		{"/search/", "", "search_song.json", 20, sunny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture+"?"+tt.query, func(t *testing.T) {
+			v, err := Translate(tt.path, tt.query, readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("Translate(%s): %v", tt.path, err)
+			}
+			songs := songsOf(t, v)
+			if len(songs) != tt.count {
+				t.Fatalf("got %d songs, want %d", len(songs), tt.count)
+			}
+			checkSong(t, songs[0], tt.first)
+			for i, s := range songs {
+				if s.Name == "" || len(s.Artists) == 0 {
+					t.Errorf("song %d has no name or artists: %+v", i, s)
+				}
+			}
+		})
+	}
+}
+
+func TestTranslateAlbumFixtures(t *testing.T) {
+	tests := []struct {
+		path, query, fixture string
+		mid, name            string
+		artist               string
+		releaseDate          string
+	}{
+		{"/album/detail", "", "album_detail.json", "004Bux530GytNj", "恒星不忘 Forever Forever", "周杰伦", "2025-12-05"},
+		{"/artist/albums", "", "singer_albums.json", "004Bux530GytNj", "恒星不忘 Forever Forever", "周杰伦", "2025-12-05"},
+		{"/recommend/new/albums", "", "recommend_new_albums.json", "000PBPTn4ZFve0", "下一站家宴", "郁可唯", "2026-02-12"},
+		{"/search/", "type=8", "search_albums.json", "0024bjiL2aocxT", "十一月的萧邦", "周杰伦", "2005-11-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			v, err := Translate(tt.path, tt.query, readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("Translate(%s): %v", tt.path, err)
+			}
+			var a Album
+			switch v := v.(type) {
+			case Album:
+				a = v
+			case List[Album]:
+				if len(v.Items) == 0 {
+					t.Fatal("no albums")
+				}
+				a = v.Items[0]
+			default:
+				t.Fatalf("translated to %T", v)
+			}
+			if a.MID != tt.mid || a.Name != tt.name || a.ReleaseDate != tt.releaseDate {
+				t.Errorf("album = %q %q %q, want %q %q %q", a.MID, a.Name, a.ReleaseDate, tt.mid, tt.name, tt.releaseDate)
+			}
+			if len(a.Artists) == 0 || a.Artists[0].Name != tt.artist {
+				t.Errorf("artists = %q, want first %q", artistNames(a.Artists), tt.artist)
+			}
+		})
+	}
+}
+
+func TestTranslateMVFixtures(t *testing.T) {
+	tests := []struct {
+		path, query, fixture string
+		vid, name, artist    string
+		duration             int
+	}{
+		{"/mv/list", "", "mv_category_list.json", "001EQKJI0uXvPY", "CapitaliZoo", "Beren", 219},
+		{"/mv/detail", "", "mv_detail.json", "d0032k98yoy", "说好不哭 (with 五月天阿信) (KTV版)", "周杰伦", 231},
+		{"/artist/mvs", "", "singer_mvs.json", "d0032k98yoy", "说好不哭 (with 五月天阿信) (KTV版)", "周杰伦", 0},
+		{"/search/", "type=12", "search_mv.json", "w0026q7f01a", "晴天", "周杰伦", 317},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			v, err := Translate(tt.path, tt.query, readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("Translate(%s): %v", tt.path, err)
+			}
+			var mv MV
+			switch v := v.(type) {
+			case MV:
+				mv = v
+			case List[MV]:
+				if len(v.Items) == 0 {
+					t.Fatal("no MVs")
+				}
+				mv = v.Items[0]
+			default:
+				t.Fatalf("translated to %T", v)
+			}
+			if mv.VID != tt.vid || mv.Name != tt.name || mv.DurationSec != tt.duration {
+				t.Errorf("mv = %q %q %d, want %q %q %d", mv.VID, mv.Name, mv.DurationSec, tt.vid, tt.name, tt.duration)
+			}
+			if len(mv.Artists) == 0 || mv.Artists[0].Name != tt.artist {
+				t.Errorf("artists = %q, want first %q", artistNames(mv.Artists), tt.artist)
+			}
+		})
+	}
+}
+
+func TestTranslateArtistFixtures(t *testing.T) {
+	tests := []struct {
+		path, query, fixture string
+		mid, name            string
+	}{
+		{"/artist/list", "", "singer_filter_list.json", "0025NhlN2yWrP4", "周杰伦"},
+		{"/search/", "type=9", "search_singer.json", "0025NhlN2yWrP4", "周杰伦"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			v, err := Translate(tt.path, tt.query, readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("Translate(%s): %v", tt.path, err)
+			}
+			list, ok := v.(List[Artist])
+			if !ok || len(list.Items) == 0 {
+				t.Fatalf("translated to %T with no artists", v)
+			}
+			if a := list.Items[0]; a.MID != tt.mid || a.Name != tt.name {
+				t.Errorf("artist = %q %q, want %q %q", a.MID, a.Name, tt.mid, tt.name)
+			}
+		})
+	}
+}
+
+func TestTranslateUpstreamFailure(t *testing.T) {
+	_, err := Translate("/song/detail", "", []byte(`{"code":0,"message":"not found","data":null}`))
+	if !errors.Is(err, ErrUpstreamCode) {
+		t.Fatalf("err = %v, want ErrUpstreamCode", err)
+	}
+	if _, err := Translate("/recommend/banner", "", readFixture(t, "recommend_banner.json")); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("err = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestFromJooxSearch(t *testing.T) {
+	body := []byte(`[{"id":12345,"name":"晴天","artist":["周杰伦"],"album":"叶惠美","pic_id":"x","duration":"269"},
+		{"id":"abc","name":"晴天 (Live)","artist":[],"album":""}]`)
+	songs, err := FromJooxSearch(body)
+	if err != nil {
+		t.Fatalf("FromJooxSearch: %v", err)
+	}
+	if len(songs) != 2 {
+		t.Fatalf("got %d songs, want 2", len(songs))
+	}
+	checkSong(t, songs[0], wantSong{"12345", "晴天", []string{"周杰伦"}, "", "叶惠美", 269})
+	if songs[1].Album != nil || len(songs[1].Artists) != 0 {
+		t.Errorf("second song = %+v, want no album and no artists", songs[1])
+	}
+}
@@ -0,0 +1,38 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jooxSearchItem is one result of the fallback API's types=search&source=joox.
+type jooxSearchItem struct {
+	ID     flexString `json:"id"`
+	Name   string     `json:"name"`
+	Artist []string   `json:"artist"`
+	Album  string     `json:"album"`
+	PicID  flexString `json:"pic_id"`
+	// Duration (seconds) is only returned by some fallback API deployments.
+	Duration flexInt `json:"duration"`
+}
+
+// FromJooxSearch translates a fallback-API Joox search response into songs.
+// The Joox id is carried in Song.MID; it is only meaningful to the fallback API.
+func FromJooxSearch(body []byte) ([]Song, error) {
+	var items []jooxSearchItem
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("catalog: decode joox search: %w", err)
+	}
+	songs := make([]Song, 0, len(items))
+	for _, it := range items {
+		s := Song{MID: string(it.ID), Name: it.Name, DurationSec: int(it.Duration), Artists: make([]Artist, 0, len(it.Artist))}
+		for _, a := range it.Artist {
+			s.Artists = append(s.Artists, Artist{Name: a})
+		}
+		if it.Album != "" {
+			s.Album = &Album{Name: it.Album}
+		}
+		songs = append(songs, s)
+	}
+	return songs, nil
+}
@@ -0,0 +1,739 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// qqTranslators maps primary-upstream (QQ Music style) paths to translators.
+// Keys are the upstream paths handlers pass to handle()/handleWithQuery().
+var qqTranslators = map[string]translator{
+	"/song/detail":          qqSongDetail,
+	"/album/detail":         qqAlbumDetail,
+	"/album/songs":          qqTrackList,
+	"/artist/detail":        qqArtistDetail,
+	"/artist/songs":         qqTrackList,
+	"/artist/albums":        qqArtistAlbums,
+	"/artist/mvs":           qqArtistMVs,
+	"/artist/list":          qqArtistList,
+	"/recommend/new/songs":  qqTrackList,
+	"/recommend/new/albums": qqNewAlbums,
+	"/recommend/daily":      qqDissList,
+	"/recommend/playlist":   qqRecommendPlaylists,
+	"/playlist/detail":      qqDissList,
+	"/playlist/information": qqPlaylistInformation,
+	"/radio/songlist":       qqRadioSongs,
+	"/rankings/detail":      qqRankingDetail,
+	"/mv/list":              qqMVList,
+	"/mv/detail":            qqMVDetail,
+	"/search/":              qqSearch,
+	"/lyric/":               qqLyric,
+}
+
+// ── envelope ─────────────────────────────────────────────────────────────────
+
+// qqUnwrap checks {"code": 1, "data": …} and decodes data into v.
+func qqUnwrap(body []byte, v interface{}) error {
+	var env struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &env); err != nil {
+		return fmt.Errorf("catalog: decode envelope: %w", err)
+	}
+	if env.Code != 1 {
+		return fmt.Errorf("%w: code=%d %s", ErrUpstreamCode, env.Code, env.Message)
+	}
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		return fmt.Errorf("catalog: decode data: %w", err)
+	}
+	return nil
+}
+
+// ── shared raw shapes ────────────────────────────────────────────────────────
+
+type qqSinger struct {
+	MID   string `json:"mid"`
+	Name  string `json:"name"`
+	Trans string `json:"transName"`
+}
+
+func (s qqSinger) artist() Artist {
+	return Artist{MID: s.MID, Name: s.Name, AltName: s.Trans}
+}
+
+func qqArtists(ss []qqSinger) []Artist {
+	out := make([]Artist, 0, len(ss))
+	for _, s := range ss {
+		out = append(out, s.artist())
+	}
+	return out
+}
+
+// qqTrack is the modern track shape used by song detail, album/artist songs,
+// new songs and radio.
+type qqTrack struct {
+	MID      string     `json:"mid"`
+	Name     string     `json:"name"`
+	Subtitle string     `json:"subtitle"`
+	Singer   []qqSinger `json:"singer"`
+	Album    struct {
+		MID  string `json:"mid"`
+		Name string `json:"name"`
+	} `json:"album"`
+	MV struct {
+		VID string `json:"vid"`
+	} `json:"mv"`
+	Interval   int    `json:"interval"`
+	TimePublic string `json:"time_public"`
+}
+
+func (t qqTrack) song() Song {
+	s := Song{
+		MID:         t.MID,
+		Name:        t.Name,
+		Subtitle:    t.Subtitle,
+		Artists:     qqArtists(t.Singer),
+		DurationSec: t.Interval,
+		MVVID:       t.MV.VID,
+		ReleaseDate: t.TimePublic,
+	}
+	if t.Album.MID != "" {
+		s.Album = &Album{MID: t.Album.MID, Name: t.Album.Name, CoverURL: qqAlbumCover(t.Album.MID)}
+	}
+	return s
+}
+
+func qqTracks(ts []qqTrack) []Song {
+	out := make([]Song, 0, len(ts))
+	for _, t := range ts {
+		out = append(out, t.song())
+	}
+	return out
+}
+
+// qqLegacySong is the older flat shape used by search and playlist detail.
+type qqLegacySong struct {
+	SongMID   string     `json:"songmid"`
+	SongName  string     `json:"songname"`
+	Singer    []qqSinger `json:"singer"`
+	AlbumMID  string     `json:"albummid"`
+	AlbumName string     `json:"albumname"`
+	Interval  int        `json:"interval"`
+	VID       string     `json:"vid"`
+	PubTime   int64      `json:"pubtime"`
+}
+
+func (l qqLegacySong) song() Song {
+	s := Song{
+		MID:         l.SongMID,
+		Name:        l.SongName,
+		Artists:     qqArtists(l.Singer),
+		DurationSec: l.Interval,
+		MVVID:       l.VID,
+		ReleaseDate: unixDate(l.PubTime),
+	}
+	if l.AlbumMID != "" {
+		s.Album = &Album{MID: l.AlbumMID, Name: l.AlbumName, CoverURL: qqAlbumCover(l.AlbumMID)}
+	}
+	return s
+}
+
+func qqLegacySongs(ls []qqLegacySong) []Song {
+	out := make([]Song, 0, len(ls))
+	for _, l := range ls {
+		out = append(out, l.song())
+	}
+	return out
+}
+
+// ── songs ────────────────────────────────────────────────────────────────────
+
+func qqSongDetail(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		TrackInfo qqTrack `json:"track_info"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	return d.TrackInfo.song(), nil
+}
+
+// qqTrackList handles every {"list": [track…], "total"?} payload.
+func qqTrackList(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		List  []qqTrack `json:"list"`
+		Total int       `json:"total"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	return newList(qqTracks(d.List), d.Total), nil
+}
+
+func qqRadioSongs(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		ID     flexString `json:"id"`
+		Name   string     `json:"name"`
+		Tracks []qqTrack  `json:"tracks"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	songs := qqTracks(d.Tracks)
+	return Playlist{ID: string(d.ID), Name: d.Name, SongCount: len(songs), Songs: songs}, nil
+}
+
+func qqRankingDetail(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		TopID     flexString `json:"topId"`
+		Title     string     `json:"title"`
+		Intro     string     `json:"intro"`
+		ListenNum int64      `json:"listenNum"`
+		TotalNum  int        `json:"totalNum"`
+		FrontPic  string     `json:"frontPicUrl"`
+		Song      []struct {
+			Rank       int    `json:"rank"`
+			Title      string `json:"title"`
+			SingerName string `json:"singerName"`
+			SingerMID  string `json:"singerMid"`
+			AlbumMID   string `json:"albumMid"`
+			Cover      string `json:"cover"`
+			VID        string `json:"vid"`
+		} `json:"song"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	songs := make([]Song, 0, len(d.Song))
+	for _, s := range d.Song {
+		song := Song{
+			Name:    s.Title,
+			Artists: []Artist{{MID: s.SingerMID, Name: s.SingerName}},
+			MVVID:   s.VID,
+			Rank:    s.Rank,
+		}
+		if s.AlbumMID != "" {
+			song.Album = &Album{MID: s.AlbumMID, CoverURL: s.Cover}
+		}
+		songs = append(songs, song)
+	}
+	return Playlist{
+		ID:          string(d.TopID),
+		Name:        d.Title,
+		CoverURL:    d.FrontPic,
+		Description: d.Intro,
+		PlayCount:   d.ListenNum,
+		SongCount:   d.TotalNum,
+		Songs:       songs,
+	}, nil
+}
+
+// ── albums ───────────────────────────────────────────────────────────────────
+
+func qqAlbumDetail(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		BasicInfo struct {
+			AlbumMID    string `json:"albumMid"`
+			AlbumName   string `json:"albumName"`
+			PublishDate string `json:"publishDate"`
+			Desc        string `json:"desc"`
+		} `json:"basicInfo"`
+		Company struct {
+			Name string `json:"name"`
+		} `json:"company"`
+		Singer struct {
+			SingerList []qqSinger `json:"singerList"`
+		} `json:"singer"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	b := d.BasicInfo
+	return Album{
+		MID:         b.AlbumMID,
+		Name:        b.AlbumName,
+		CoverURL:    qqAlbumCover(b.AlbumMID),
+		Artists:     qqArtists(d.Singer.SingerList),
+		ReleaseDate: b.PublishDate,
+		Description: b.Desc,
+		Company:     d.Company.Name,
+	}, nil
+}
+
+func qqArtistAlbums(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		List []struct {
+			AlbumMID  string `json:"album_mid"`
+			AlbumName string `json:"album_name"`
+			PubTime   string `json:"pub_time"`
+			Desc      string `json:"desc"`
+			Singers   []struct {
+				MID  string `json:"singer_mid"`
+				Name string `json:"singer_name"`
+			} `json:"singers"`
+			LatestSong struct {
+				SongCount int `json:"song_count"`
+			} `json:"latest_song"`
+		} `json:"list"`
+		Total int `json:"total"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	albums := make([]Album, 0, len(d.List))
+	for _, a := range d.List {
+		artists := make([]Artist, 0, len(a.Singers))
+		for _, s := range a.Singers {
+			artists = append(artists, Artist{MID: s.MID, Name: s.Name})
+		}
+		albums = append(albums, Album{
+			MID:         a.AlbumMID,
+			Name:        a.AlbumName,
+			CoverURL:    qqAlbumCover(a.AlbumMID),
+			Artists:     artists,
+			ReleaseDate: a.PubTime,
+			Description: a.Desc,
+			TrackCount:  a.LatestSong.SongCount,
+		})
+	}
+	return newList(albums, d.Total), nil
+}
+
+func qqNewAlbums(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		List []struct {
+			MID         string     `json:"mid"`
+			Name        string     `json:"name"`
+			Singers     []qqSinger `json:"singers"`
+			ReleaseTime string     `json:"release_time"`
+		} `json:"list"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	albums := make([]Album, 0, len(d.List))
+	for _, a := range d.List {
+		albums = append(albums, Album{
+			MID:         a.MID,
+			Name:        a.Name,
+			CoverURL:    qqAlbumCover(a.MID),
+			Artists:     qqArtists(a.Singers),
+			ReleaseDate: a.ReleaseTime,
+		})
+	}
+	return newList(albums, 0), nil
+}
+
+// ── artists ──────────────────────────────────────────────────────────────────
+
+func qqArtistDetail(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		SingerInfo struct {
+			MID       string `json:"mid"`
+			Name      string `json:"name"`
+			OtherName string `json:"other_name"`
+		} `json:"singer_info"`
+		SingerBrief string    `json:"singer_brief"`
+		TotalAlbum  int       `json:"total_album"`
+		TotalMV     int       `json:"total_mv"`
+		TotalSong   int       `json:"total_song"`
+		SongList    []qqTrack `json:"songlist"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	return ArtistDetail{
+		Artist: Artist{
+			MID:        d.SingerInfo.MID,
+			Name:       d.SingerInfo.Name,
+			AltName:    d.SingerInfo.OtherName,
+			AvatarURL:  qqArtistAvatar(d.SingerInfo.MID),
+			Brief:      d.SingerBrief,
+			SongCount:  d.TotalSong,
+			AlbumCount: d.TotalAlbum,
+			MVCount:    d.TotalMV,
+		},
+		Songs: qqTracks(d.SongList),
+	}, nil
+}
+
+func qqArtistList(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		Total      int `json:"total"`
+		SingerList []struct {
+			MID       string `json:"singer_mid"`
+			Name      string `json:"singer_name"`
+			OtherName string `json:"other_name"`
+			Pic       string `json:"singer_pic"`
+		} `json:"singerlist"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	artists := make([]Artist, 0, len(d.SingerList))
+	for _, s := range d.SingerList {
+		avatar := s.Pic
+		if avatar == "" {
+			avatar = qqArtistAvatar(s.MID)
+		}
+		artists = append(artists, Artist{MID: s.MID, Name: s.Name, AltName: s.OtherName, AvatarURL: avatar})
+	}
+	return newList(artists, d.Total), nil
+}
+
+// ── playlists ────────────────────────────────────────────────────────────────
+
+// qqDissList handles the "disst" playlist detail shape, which the upstream
+// wraps in a one-element array (used by /playlist/detail and /recommend/daily).
+func qqDissList(_ url.Values, body []byte) (interface{}, error) {
+	var d []struct {
+		DissTID  flexString     `json:"disstid"`
+		DissName string         `json:"dissname"`
+		Logo     string         `json:"logo"`
+		Desc     string         `json:"desc"`
+		Nickname string         `json:"nickname"`
+		VisitNum int64          `json:"visitnum"`
+		SongNum  int            `json:"songnum"`
+		SongList []qqLegacySong `json:"songlist"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	if len(d) == 0 {
+		return nil, fmt.Errorf("%w: empty playlist payload", ErrUpstreamCode)
+	}
+	p := d[0]
+	return Playlist{
+		ID:          string(p.DissTID),
+		Name:        p.DissName,
+		CoverURL:    p.Logo,
+		Description: p.Desc,
+		Creator:     p.Nickname,
+		PlayCount:   p.VisitNum,
+		SongCount:   p.SongNum,
+		Songs:       qqLegacySongs(p.SongList),
+	}, nil
+}
+
+func qqRecommendPlaylists(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		Total int `json:"total"`
+		List  []struct {
+			TID         flexString `json:"tid"`
+			Title       string     `json:"title"`
+			Desc        string     `json:"desc"`
+			CoverMedium string     `json:"cover_url_medium"`
+			AccessNum   int64      `json:"access_num"`
+			SongIDs     []flexInt  `json:"song_ids"`
+			CreatorInfo struct {
+				Nick string `json:"nick"`
+			} `json:"creator_info"`
+		} `json:"list"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	pls := make([]Playlist, 0, len(d.List))
+	for _, p := range d.List {
+		pls = append(pls, Playlist{
+			ID:          string(p.TID),
+			Name:        p.Title,
+			CoverURL:    p.CoverMedium,
+			Description: p.Desc,
+			Creator:     p.CreatorInfo.Nick,
+			PlayCount:   p.AccessNum,
+			SongCount:   len(p.SongIDs),
+		})
+	}
+	return newList(pls, d.Total), nil
+}
+
+func qqPlaylistInformation(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		Total int `json:"total"`
+		List  []struct {
+			DissID       flexString `json:"dissid"`
+			DissName     string     `json:"dissname"`
+			ImgURL       string     `json:"imgurl"`
+			Introduction string     `json:"introduction"`
+			ListenNum    int64      `json:"listennum"`
+			Creator      struct {
+				Name string `json:"name"`
+			} `json:"creator"`
+		} `json:"list"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	pls := make([]Playlist, 0, len(d.List))
+	for _, p := range d.List {
+		pls = append(pls, Playlist{
+			ID:          string(p.DissID),
+			Name:        p.DissName,
+			CoverURL:    p.ImgURL,
+			Description: p.Introduction,
+			Creator:     p.Creator.Name,
+			PlayCount:   p.ListenNum,
+		})
+	}
+	return newList(pls, d.Total), nil
+}
+
+// ── MVs ──────────────────────────────────────────────────────────────────────
+
+type qqMVSinger struct {
+	MID  string `json:"mid"`
+	Name string `json:"name"`
+}
+
+func qqMVArtists(ss []qqMVSinger) []Artist {
+	out := make([]Artist, 0, len(ss))
+	for _, s := range ss {
+		out = append(out, Artist{MID: s.MID, Name: s.Name})
+	}
+	return out
+}
+
+func qqMVList(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		Total int `json:"total"`
+		List  []struct {
+			VID      string       `json:"vid"`
+			Title    string       `json:"title"`
+			PicURL   string       `json:"picurl"`
+			Duration int          `json:"duration"`
+			PlayCnt  int64        `json:"playcnt"`
+			PubDate  int64        `json:"pubdate"`
+			Singers  []qqMVSinger `json:"singers"`
+		} `json:"list"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	mvs := make([]MV, 0, len(d.List))
+	for _, m := range d.List {
+		mvs = append(mvs, MV{
+			VID:         m.VID,
+			Name:        m.Title,
+			CoverURL:    m.PicURL,
+			Artists:     qqMVArtists(m.Singers),
+			DurationSec: m.Duration,
+			PlayCount:   m.PlayCnt,
+			ReleaseDate: unixDate(m.PubDate),
+		})
+	}
+	return newList(mvs, d.Total), nil
+}
+
+func qqMVDetail(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		Info struct {
+			VID      string       `json:"vid"`
+			Name     string       `json:"name"`
+			CoverPic string       `json:"cover_pic"`
+			Duration int          `json:"duration"`
+			PlayCnt  int64        `json:"playcnt"`
+			PubDate  int64        `json:"pubdate"`
+			Singers  []qqMVSinger `json:"singers"`
+		} `json:"info"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	m := d.Info
+	return MV{
+		VID:         m.VID,
+		Name:        m.Name,
+		CoverURL:    m.CoverPic,
+		Artists:     qqMVArtists(m.Singers),
+		DurationSec: m.Duration,
+		PlayCount:   m.PlayCnt,
+		ReleaseDate: unixDate(m.PubDate),
+	}, nil
+}
+
+func qqArtistMVs(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		Total int `json:"total"`
+		List  []struct {
+			VID         string  `json:"vid"`
+			Title       string  `json:"title"`
+			Pic         string  `json:"pic"`
+			ListenCount flexInt `json:"listenCount"`
+			Date        string  `json:"date"`
+			SingerMID   string  `json:"singer_mid"`
+			SingerName  string  `json:"singer_name"`
+		} `json:"list"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	mvs := make([]MV, 0, len(d.List))
+	for _, m := range d.List {
+		mvs = append(mvs, MV{
+			VID:         m.VID,
+			Name:        m.Title,
+			CoverURL:    m.Pic,
+			Artists:     []Artist{{MID: m.SingerMID, Name: m.SingerName}},
+			PlayCount:   int64(m.ListenCount),
+			ReleaseDate: m.Date,
+		})
+	}
+	return newList(mvs, d.Total), nil
+}
+
+// ── search ───────────────────────────────────────────────────────────────────
+
+// Upstream /search/ type codes.
+const (
+	qqSearchSong   = "0"
+	qqSearchAlbum  = "8"
+	qqSearchArtist = "9"
+	qqSearchMV     = "12"
+)
+
+func qqSearch(q url.Values, body []byte) (interface{}, error) {
+	switch q.Get("type") {
+	case "", qqSearchSong:
+		var d struct {
+			Total int            `json:"total"`
+			List  []qqLegacySong `json:"list"`
+		}
+		if err := qqUnwrap(body, &d); err != nil {
+			return nil, err
+		}
+		return newList(qqLegacySongs(d.List), d.Total), nil
+
+	case qqSearchArtist:
+		var d struct {
+			Total int `json:"total"`
+			List  []struct {
+				MID      string `json:"singerMID"`
+				Name     string `json:"singerName"`
+				Pic      string `json:"singerPic"`
+				SongNum  int    `json:"songNum"`
+				AlbumNum int    `json:"albumNum"`
+				MVNum    int    `json:"mvNum"`
+			} `json:"list"`
+		}
+		if err := qqUnwrap(body, &d); err != nil {
+			return nil, err
+		}
+		artists := make([]Artist, 0, len(d.List))
+		for _, a := range d.List {
+			artists = append(artists, Artist{
+				MID: a.MID, Name: a.Name, AvatarURL: a.Pic,
+				SongCount: a.SongNum, AlbumCount: a.AlbumNum, MVCount: a.MVNum,
+			})
+		}
+		return newList(artists, d.Total), nil
+
+	case qqSearchAlbum:
+		var d struct {
+			Total int `json:"total"`
+			List  []struct {
+				MID        string       `json:"albumMID"`
+				Name       string       `json:"albumName"`
+				Pic        string       `json:"albumPic"`
+				PublicTime string       `json:"publicTime"`
+				SongCount  int          `json:"song_count"`
+				SingerList []qqMVSinger `json:"singer_list"`
+			} `json:"list"`
+		}
+		if err := qqUnwrap(body, &d); err != nil {
+			return nil, err
+		}
+		albums := make([]Album, 0, len(d.List))
+		for _, a := range d.List {
+			albums = append(albums, Album{
+				MID: a.MID, Name: a.Name, CoverURL: a.Pic,
+				Artists:     qqMVArtists(a.SingerList),
+				ReleaseDate: a.PublicTime,
+				TrackCount:  a.SongCount,
+			})
+		}
+		return newList(albums, d.Total), nil
+
+	case qqSearchMV:
+		var d struct {
+			Total int `json:"total"`
+			List  []struct {
+				VID         string       `json:"v_id"`
+				Name        string       `json:"mv_name"`
+				Pic         string       `json:"mv_pic_url"`
+				Duration    int          `json:"duration"`
+				PlayCount   int64        `json:"play_count"`
+				PublishDate string       `json:"publish_date"`
+				SingerList  []qqMVSinger `json:"singer_list"`
+			} `json:"list"`
+		}
+		if err := qqUnwrap(body, &d); err != nil {
+			return nil, err
+		}
+		mvs := make([]MV, 0, len(d.List))
+		for _, m := range d.List {
+			mvs = append(mvs, MV{
+				VID: m.VID, Name: m.Name, CoverURL: m.Pic,
+				Artists:     qqMVArtists(m.SingerList),
+				DurationSec: m.Duration,
+				PlayCount:   m.PlayCount,
+				ReleaseDate: m.PublishDate,
+			})
+		}
+		return newList(mvs, d.Total), nil
+	}
+	return nil, ErrUnsupported
+}
+
+// ── lyric ────────────────────────────────────────────────────────────────────
+
+func qqLyric(_ url.Values, body []byte) (interface{}, error) {
+	var d struct {
+		Lyric string `json:"lyric"`
+		Trans string `json:"trans"`
+	}
+	if err := qqUnwrap(body, &d); err != nil {
+		return nil, err
+	}
+	return Lyric{LRC: d.Lyric, Translation: d.Trans}, nil
+}
+
+// ── helpers ──────────────────────────────────────────────────────────────────
+
+func newList[T any](items []T, total int) List[T] {
+	if total == 0 {
+		total = len(items)
+	}
+	return List[T]{Items: items, Total: total}
+}
+
+// qqAlbumCover builds the public CDN cover URL for an album mid.
+func qqAlbumCover(mid string) string {
+	if mid == "" {
+		return ""
+	}
+	return "https://y.gtimg.cn/music/photo_new/T002R300x300M000" + mid + ".jpg"
+}
+
+// qqArtistAvatar builds the public CDN avatar URL for a singer mid.
+func qqArtistAvatar(mid string) string {
+	if mid == "" {
+		return ""
+	}
+	return "https://y.gtimg.cn/music/photo_new/T001R300x300M000" + mid + ".jpg"
+}
+
+// unixDate formats a Unix-seconds timestamp as YYYY-MM-DD (China time, as the
+// upstream's own date strings are). Zero yields "".
+func unixDate(ts int64) string {
+	if ts <= 0 {
+		return ""
+	}
+	return time.Unix(ts, 0).In(cst).Format(time.DateOnly)
+}
+
+var cst = time.FixedZone("CST", 8*3600)
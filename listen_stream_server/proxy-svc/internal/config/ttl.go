@@ -1,37 +1,63 @@
-// Package config defines proxy-specific configuration constants.
+// Package config defines proxy-specific configuration.
 package config
 
-import "time"
-
-// ProxyTTL maps upstream path prefixes to Redis cache TTLs.
-// TTL == 0 means the response is NOT cached (always forwarded to upstream).
-var ProxyTTL = map[string]time.Duration{
-	"/recommend/banner":     30 * time.Minute,
-	"/recommend/daily":      1 * time.Hour,
-	"/recommend/playlist":   1 * time.Hour,
-	"/recommend/new/songs":  30 * time.Minute,
-	"/recommend/new/albums": 30 * time.Minute,
-	"/playlist/category":    6 * time.Hour,
-	"/playlist/information": 1 * time.Hour,
-	"/playlist/detail":      6 * time.Hour,
-	"/artist/category":      6 * time.Hour,
-	"/artist/list":          2 * time.Hour,
-	"/artist/detail":        12 * time.Hour,
-	"/artist/albums":        12 * time.Hour,
-	"/artist/mvs":           12 * time.Hour,
-	"/artist/songs":         12 * time.Hour,
-	"/rankings/list":        1 * time.Hour,
-	"/rankings/detail":      1 * time.Hour,
-	"/radio/category":       6 * time.Hour,
-	"/radio/songlist":       0,              // not cached
-	"/mv/category":          6 * time.Hour,
-	"/mv/list":              1 * time.Hour,
-	"/mv/detail":            0,              // not cached
-	"/album/detail":         12 * time.Hour,
-	"/album/songs":          12 * time.Hour,
-	"/search/hotkey":        15 * time.Minute,
-	"/search":               5 * time.Minute,
-	"/song/detail":          12 * time.Hour,
-	"/song/url":             0,              // not cached - URLs expire
-	"/lyric":                7 * 24 * time.Hour,
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/config"
+	"listen-stream/shared/pkg/proxyttl"
+)
+
+// TTLs resolves per-endpoint cache TTLs: proxyttl.Defaults with the
+// PROXY_TTLS overrides from ConfigService applied. Overrides are re-read on
+// every lookup, so edits take effect within the ConfigService cache window.
+type TTLs struct {
+	cfgSvc config.Service
+	log    *zap.Logger
+
+	mu    sync.Mutex
+	raw   string                   // last PROXY_TTLS value seen
+	table map[string]time.Duration // effective table for raw
+}
+
+// NewTTLs creates a TTLs resolver.
+func NewTTLs(cfgSvc config.Service, log *zap.Logger) *TTLs {
+	return &TTLs{cfgSvc: cfgSvc, log: log, table: proxyttl.Effective(nil)}
+}
+
+// Get returns the TTL for upstreamPath. ok is false when the path has no
+// entry in proxyttl.Defaults, which is a programming error: the response is
+// then not cached and the miss is logged.
+func (t *TTLs) Get(ctx context.Context, upstreamPath string) (ttl time.Duration, ok bool) {
+	ttl, ok = t.current(ctx)[upstreamPath]
+	if !ok {
+		t.log.Error("no proxy TTL entry for upstream path; add it to proxyttl.Defaults",
+			zap.String("path", upstreamPath))
+	}
+	return ttl, ok
+}
+
+func (t *TTLs) current(ctx context.Context) map[string]time.Duration {
+	v, err := t.cfgSvc.Get(ctx, proxyttl.ConfigKey)
+	if err != nil {
+		v = ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if v == t.raw {
+		return t.table
+	}
+	overrides, perr := proxyttl.Parse(v)
+	t.raw = v
+	if perr != nil {
+		// Keep serving the last good table rather than falling back to defaults.
+		t.log.Warn("invalid PROXY_TTLS, keeping previous TTLs", zap.Error(perr))
+		return t.table
+	}
+	t.table = proxyttl.Effective(overrides)
+	return t.table
 }
@@ -0,0 +1,227 @@
+// Package cron contains scheduled tasks for proxy-svc.
+package cron
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/config"
+	"listen-stream/shared/pkg/rdb"
+)
+
+const (
+	// cfgCacheWarmCron is the cron schedule for warm passes (default: every minute).
+	cfgCacheWarmCron    = "CACHE_WARM_CRON"
+	defaultWarmSchedule = "* * * * *"
+
+	// cfgCacheWarmPaths is a comma- or newline-separated list of upstream
+	// "path?query" entries to keep warm, e.g. "/recommend/banner,/rankings/list".
+	cfgCacheWarmPaths = "CACHE_WARM_PATHS"
+
+	// cfgCacheWarmLead is how many seconds before an entry stops being fresh it
+	// is refreshed. It should exceed the schedule interval so no tick is missed.
+	cfgCacheWarmLead = "CACHE_WARM_LEAD"
+	defaultWarmLead  = 2 * time.Minute
+
+	// warmLockTTL is how long the lock lasts without renewal. The holder renews
+	// it before every entry, so it must exceed one entry's warmItemTimeout; a
+	// crashed holder frees the lock by the next tick.
+	warmLockTTL = 55 * time.Second
+	// warmItemTimeout bounds one entry's refresh.
+	warmItemTimeout = 20 * time.Second
+)
+
+// defaultWarmPaths are the home-screen endpoints whose first request after
+// expiry is otherwise always slow.
+var defaultWarmPaths = []string{
+	"/recommend/banner",
+	"/recommend/daily",
+	"/recommend/playlist",
+	"/recommend/new/songs",
+	"/recommend/new/albums",
+	"/rankings/list",
+	"/playlist/category",
+}
+
+// Warmer refreshes one cache entry if it is about to expire.
+// Implemented by *handler.ProxyHandler.
+type Warmer interface {
+	Warm(ctx context.Context, upstreamPath, rawQuery string, lead time.Duration) (refreshed bool, err error)
+}
+
+// WarmStatus is the last warm result for one entry, as published to Redis
+// (rdb.KeyCacheWarmStatus) for admin-svc.
+type WarmStatus struct {
+	Path       string    `json:"path"`
+	Query      string    `json:"query,omitempty"`
+	OK         bool      `json:"ok"`
+	Refreshed  bool      `json:"refreshed"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	At         time.Time `json:"at"`
+	Instance   string    `json:"instance"`
+}
+
+// CacheWarmCron periodically refreshes CACHE_WARM_PATHS shortly before their
+// cache TTL runs out. A Redis lock makes sure only one replica warms per tick.
+type CacheWarmCron struct {
+	cron    *cron.Cron
+	entryID cron.EntryID
+	warmer  Warmer
+	cfgSvc  config.Service
+	rdb     *rdb.Client
+	log     *zap.Logger
+}
+
+// NewCacheWarmCron creates a CacheWarmCron. Call Start to begin scheduling.
+func NewCacheWarmCron(warmer Warmer, cfgSvc config.Service, rdbClient *rdb.Client, log *zap.Logger) *CacheWarmCron {
+	return &CacheWarmCron{
+		cron:   cron.New(),
+		warmer: warmer,
+		cfgSvc: cfgSvc,
+		rdb:    rdbClient,
+		log:    log,
+	}
+}
+
+// Start reads the schedule from ConfigService and starts the scheduler.
+func (c *CacheWarmCron) Start(ctx context.Context) error {
+	schedule := defaultWarmSchedule
+	if s, err := c.cfgSvc.Get(ctx, cfgCacheWarmCron); err == nil && s != "" {
+		schedule = s
+	}
+	entryID, err := c.cron.AddFunc(schedule, func() { c.RunOnce(ctx) })
+	if err != nil {
+		return fmt.Errorf("cache warm cron: add job: %w", err)
+	}
+	c.entryID = entryID
+	c.cron.Start()
+	c.log.Info("cache warm cron started", zap.String("schedule", schedule))
+	return nil
+}
+
+// Stop halts the scheduler gracefully.
+func (c *CacheWarmCron) Stop() {
+	c.cron.Stop()
+}
+
+// RunOnce runs a single warm pass if this replica wins the lock. A pass can
+// outlast warmLockTTL (every entry may take up to warmItemTimeout), so the
+// lock is renewed before each entry and the pass stops if it was lost.
+func (c *CacheWarmCron) RunOnce(ctx context.Context) {
+	token := randomToken()
+	ok, err := c.rdb.SetNX(ctx, rdb.KeyCacheWarmLock(), token, warmLockTTL)
+	if err != nil {
+		c.log.Warn("cache warm: lock failed", zap.Error(err))
+		return
+	}
+	if !ok {
+		return // another replica is warming this tick
+	}
+	defer func() {
+		_, _ = c.rdb.DelIfEqual(context.WithoutCancel(ctx), rdb.KeyCacheWarmLock(), token)
+	}()
+
+	lead := c.lead(ctx)
+	var refreshed, failed int
+	for _, entry := range c.paths(ctx) {
+		if ctx.Err() != nil {
+			return
+		}
+		if held, err := c.rdb.ExpireIfEqual(ctx, rdb.KeyCacheWarmLock(), token, warmLockTTL); err != nil || !held {
+			c.log.Warn("cache warm: lock lost, stopping pass", zap.Int("refreshed", refreshed), zap.Int("failed", failed), zap.Error(err))
+			return
+		}
+		path, query, _ := strings.Cut(entry, "?")
+		st := c.warmOne(ctx, path, query, lead)
+		if st.Refreshed {
+			refreshed++
+		}
+		if !st.OK {
+			failed++
+		}
+	}
+	if refreshed > 0 || failed > 0 {
+		c.log.Info("cache warm pass", zap.Int("refreshed", refreshed), zap.Int("failed", failed))
+	}
+}
+
+// ── internals ────────────────────────────────────────────────────────────────
+
+func (c *CacheWarmCron) warmOne(ctx context.Context, path, query string, lead time.Duration) WarmStatus {
+	ictx, cancel := context.WithTimeout(ctx, warmItemTimeout)
+	defer cancel()
+
+	start := time.Now()
+	refreshed, err := c.warmer.Warm(ictx, path, query, lead)
+	st := WarmStatus{
+		Path:       path,
+		Query:      query,
+		OK:         err == nil,
+		Refreshed:  refreshed,
+		DurationMS: time.Since(start).Milliseconds(),
+		At:         time.Now().UTC(),
+		Instance:   instanceName,
+	}
+	if err != nil {
+		st.Error = err.Error()
+		c.log.Warn("cache warm failed", zap.String("path", path), zap.String("query", query), zap.Error(err))
+	}
+
+	field := path
+	if query != "" {
+		field += "?" + query
+	}
+	if data, merr := json.Marshal(st); merr == nil {
+		_ = c.rdb.HSet(ctx, rdb.KeyCacheWarmStatus(), field, string(data))
+	}
+	return st
+}
+
+func (c *CacheWarmCron) paths(ctx context.Context) []string {
+	v, err := c.cfgSvc.Get(ctx, cfgCacheWarmPaths)
+	if err != nil || strings.TrimSpace(v) == "" {
+		return defaultWarmPaths
+	}
+	var out []string
+	for _, p := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (c *CacheWarmCron) lead(ctx context.Context) time.Duration {
+	if v, err := c.cfgSvc.Get(ctx, cfgCacheWarmLead); err == nil {
+		if n, perr := strconv.Atoi(v); perr == nil && n > 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return defaultWarmLead
+}
+
+// instanceName identifies this replica in published warm results.
+var instanceName = func() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return h
+}()
+
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,121 @@
+// Package gateway forwards the routes proxy-svc does not serve itself to the
+// backend services that do (auth-svc, sync-svc), so clients need only one
+// origin.
+//
+// Requests and responses are streamed rather than buffered, connections are
+// pooled per backend host, WebSocket upgrades are passed through, and the
+// backend sees the client's address in X-Forwarded-For / -Host / -Proto. An
+// incoming X-Forwarded-For chain is only passed on from a trusted proxy (the
+// engine's SetTrustedProxies); anyone else's is dropped, not extended.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/tracing"
+)
+
+const (
+	// responseHeaderTimeout bounds how long a backend may take to start
+	// answering. Bodies themselves are not time-limited so streams and
+	// WebSockets can stay open.
+	responseHeaderTimeout = 30 * time.Second
+	maxIdleConnsPerHost   = 64
+	idleConnTimeout       = 90 * time.Second
+)
+
+// Gateway builds reverse proxies that share one pooled transport.
+type Gateway struct {
+	transport *http.Transport
+	log       *zap.Logger
+}
+
+// New returns a Gateway with a transport tuned for a few busy backends.
+func New(log *zap.Logger) *Gateway {
+	return &Gateway{
+		transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   5 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          4 * maxIdleConnsPerHost,
+			MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+			IdleConnTimeout:       idleConnTimeout,
+			ResponseHeaderTimeout: responseHeaderTimeout,
+			ExpectContinueTimeout: time.Second,
+		},
+		log: log,
+	}
+}
+
+// Proxy returns a handler forwarding requests unchanged (same path and query)
+// to the service at target, e.g. "http://auth-svc:8001". name labels logs
+// and error responses.
+func (g *Gateway) Proxy(name, target string) (gin.HandlerFunc, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("gateway: %s: target must be an absolute http(s) URL, got %q", name, target)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(u)
+			// Keep the chain from a trusted load balancer in front of us (the
+			// handler drops any other); SetXForwarded appends the immediate
+			// client and sets -Host / -Proto.
+			if xff := r.In.Header["X-Forwarded-For"]; len(xff) > 0 {
+				r.Out.Header["X-Forwarded-For"] = xff
+			}
+			r.SetXForwarded()
+		},
+		// Client span per forward; the backend continues the trace from the
+		// traceparent header it injects.
+		Transport: tracing.Transport(g.transport),
+		// Flush every write so streamed bodies (SSE, chunked) are not held back.
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, context.Canceled) && r.Context().Err() != nil {
+				// Client went away; nobody is left to answer.
+				return
+			}
+			g.log.Error("gateway: forward request", zap.String("service", name),
+				zap.String("path", r.URL.Path), zap.Error(err))
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = fmt.Fprintf(w, `{"code":"SERVICE_UNAVAILABLE","message":"%s unavailable"}`, name)
+		},
+		ErrorLog: zap.NewStdLog(g.log),
+	}
+	return func(c *gin.Context) {
+		// ClientIP only looks past the peer when it is a trusted proxy, so an
+		// equal RemoteIP means the chain came from the client: don't vouch for it.
+		if c.ClientIP() == c.RemoteIP() {
+			c.Request.Header.Del("X-Forwarded-For")
+		}
+		rp.ServeHTTP(c.Writer, c.Request)
+	}, nil
+}
+
+// Mount routes prefix itself and everything below it to h, for every method.
+func Mount(r gin.IRoutes, prefix string, h gin.HandlerFunc) {
+	r.Any(prefix, h)
+	r.Any(prefix+"/*path", h)
+}
+
+// Close drops idle backend connections; call it after the server has shut down.
+func (g *Gateway) Close() {
+	g.transport.CloseIdleConnections()
+}
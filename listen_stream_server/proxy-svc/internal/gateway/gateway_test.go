@@ -0,0 +1,278 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/tracing"
+)
+
+var spans = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	shutdown, err := tracing.Setup(context.Background(), "proxy-svc", tracing.WithExporter(spans))
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	_ = shutdown(context.Background())
+	os.Exit(code)
+}
+
+// TestGatewayInjectsTraceparent checks that a forwarded request carries a
+// traceparent naming the gateway's client span, itself a child of the
+// incoming request's server span, so the backend continues the same trace.
+func TestGatewayInjectsTraceparent(t *testing.T) {
+	spans.Reset()
+	var traceparent string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer backend.Close()
+
+	g := New(zap.NewNop())
+	defer g.Close()
+	h, err := g.Proxy("auth-svc", backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(tracing.Middleware("proxy-svc"))
+	Mount(r, "/auth", h)
+
+	// A real server: ReverseProxy needs a ResponseWriter with CloseNotify.
+	front := httptest.NewServer(r)
+	defer front.Close()
+	resp, err := http.Post(front.URL+"/auth/login", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	// The response can reach us before the server span ends; wait for it.
+	got := spans.GetSpans()
+	for deadline := time.Now().Add(time.Second); len(got) < 2 && time.Now().Before(deadline); got = spans.GetSpans() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	var server, client *tracetest.SpanStub
+	for i := range got {
+		switch got[i].SpanKind {
+		case trace.SpanKindServer:
+			server = &got[i]
+		case trace.SpanKindClient:
+			client = &got[i]
+		}
+	}
+	if server == nil || client == nil {
+		t.Fatalf("want a server and a client span, got %d spans", len(got))
+	}
+	if client.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("gateway client span is not a child of the server span")
+	}
+
+	sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(),
+		propagation.HeaderCarrier{"Traceparent": {traceparent}}))
+	if !sc.IsValid() {
+		t.Fatalf("backend received no valid traceparent (%q)", traceparent)
+	}
+	if sc.TraceID() != server.SpanContext.TraceID() || sc.SpanID() != client.SpanContext.SpanID() {
+		t.Errorf("traceparent %q does not name the gateway client span", traceparent)
+	}
+}
+
+// front serves Mount(prefix) → target through a real server, trusting
+// X-Forwarded-For from trusted only.
+func front(t *testing.T, prefix, target string, trusted []string) *httptest.Server {
+	t.Helper()
+	g := New(zap.NewNop())
+	t.Cleanup(g.Close)
+	h, err := g.Proxy("sync-svc", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(trusted); err != nil {
+		t.Fatal(err)
+	}
+	Mount(r, prefix, h)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGatewayStreamsBody(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = io.WriteString(w, "data: second\n\n")
+	}))
+	defer backend.Close()
+	defer close(release)
+	srv := front(t, "/api/history", backend.URL, nil)
+
+	resp, err := http.Get(srv.URL + "/api/history/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	// The backend holds the rest of the body until we have the first event,
+	// so reading it proves the gateway did not buffer the response.
+	got := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		got <- line
+	}()
+	select {
+	case line := <-got:
+		if line != "data: first\n" {
+			t.Fatalf("first line = %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("first event not delivered before the body finished: response is buffered")
+	}
+}
+
+func TestGatewayWebSocketUpgrade(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" || r.URL.Path != "/ws" {
+			http.Error(w, "want a websocket upgrade on /ws", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		_ = rw.Flush()
+		// Echo one line so the test can check bytes flow both ways.
+		line, _ := rw.ReadString('\n')
+		_, _ = rw.WriteString("echo " + line)
+		_ = rw.Flush()
+	}))
+	defer backend.Close()
+	srv := front(t, "/ws", backend.URL, nil)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, _ = io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: example.com\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	_, _ = io.WriteString(conn, "hello\n")
+	line, err := br.ReadString('\n')
+	if err != nil || line != "echo hello\n" {
+		t.Fatalf("after upgrade read %q, %v", line, err)
+	}
+}
+
+func TestGatewayForwardedHeaders(t *testing.T) {
+	cases := []struct {
+		name    string
+		trusted []string
+		xff     string
+		want    string
+	}{
+		{"no chain", nil, "", "127.0.0.1"},
+		{"untrusted peer's chain dropped", nil, "6.6.6.6", "127.0.0.1"},
+		{"trusted peer's chain kept", []string{"127.0.0.1"}, "203.0.113.7", "203.0.113.7, 127.0.0.1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got http.Header
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer backend.Close()
+			srv := front(t, "/api/favorites", backend.URL, tc.trusted)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/favorites", nil)
+			req.Host = "music.example.com"
+			if tc.xff != "" {
+				req.Header.Set("X-Forwarded-For", tc.xff)
+			}
+			req.Header.Set("X-Forwarded-Host", "spoofed.example.com")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if v := strings.Join(got.Values("X-Forwarded-For"), ", "); v != tc.want {
+				t.Errorf("X-Forwarded-For = %q, want %q", v, tc.want)
+			}
+			if v := got.Get("X-Forwarded-Host"); v != "music.example.com" {
+				t.Errorf("X-Forwarded-Host = %q", v)
+			}
+			if v := got.Get("X-Forwarded-Proto"); v != "http" {
+				t.Errorf("X-Forwarded-Proto = %q", v)
+			}
+		})
+	}
+}
+
+func TestGatewayBackendDown(t *testing.T) {
+	backend := httptest.NewServer(http.NotFoundHandler())
+	target := backend.URL
+	backend.Close() // connections to target are now refused
+	srv := front(t, "/auth", target, nil)
+
+	resp, err := http.Get(srv.URL + "/auth/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var body struct{ Code, Message string }
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != "SERVICE_UNAVAILABLE" || body.Message != "sync-svc unavailable" {
+		t.Errorf("body = %+v", body)
+	}
+}
@@ -1,8 +1,6 @@
 package handler
 
 import (
-	pxcfg "listen-stream/proxy-svc/internal/config"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,9 +16,9 @@ func (h *AlbumHandler) Register(rg *gin.RouterGroup) {
 
 func (h *AlbumHandler) detail(c *gin.Context) {
 	// Client sends 'mid', upstream expects 'id'
-	h.handleWithParamMap(c, "/album/detail", pxcfg.ProxyTTL["/album/detail"], map[string]string{"mid": "id"})
+	h.handleWithParamMap(c, "/album/detail", map[string]string{"mid": "id"})
 }
 func (h *AlbumHandler) songs(c *gin.Context) {
 	// Client sends 'mid', upstream expects 'id'
-	h.handleWithParamMap(c, "/album/songs", pxcfg.ProxyTTL["/album/songs"], map[string]string{"mid": "id"})
+	h.handleWithParamMap(c, "/album/songs", map[string]string{"mid": "id"})
 }
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	proxymw "listen-stream/proxy-svc/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// cfgBatchMaxItems caps the number of items in one POST /api/batch.
+	cfgBatchMaxItems     = "BATCH_MAX_ITEMS"
+	defaultBatchMaxItems = 20
+)
+
+// batchRoutes are the API paths (without /api) an item may name: the JSON
+// endpoints. Anything else is refused, notably /song/stream (audio would be
+// buffered whole in memory) and /batch itself (batches must not nest).
+var batchRoutes = map[string]bool{
+	"/recommend/banner": true, "/recommend/daily": true, "/recommend/playlist": true,
+	"/recommend/new-songs": true, "/recommend/new-albums": true,
+	"/playlist/category": true, "/playlist/information": true, "/playlist/detail": true,
+	"/artist/category": true, "/artist/list": true, "/artist/detail": true,
+	"/artist/albums": true, "/artist/mvs": true, "/artist/songs": true,
+	"/ranking/list": true, "/ranking/detail": true,
+	"/radio/category": true, "/radio/songlist": true,
+	"/mv/category": true, "/mv/list": true, "/mv/detail": true,
+	"/album/detail": true, "/album/songs": true,
+	"/search": true, "/search/hotkey": true, "/search/songs": true, "/search/singers": true,
+	"/search/albums": true, "/search/mvs": true, "/search/all": true,
+	"/lyric": true, "/song/detail": true, "/song/url": true,
+}
+
+// BatchHandler serves POST /api/batch: several proxied GETs in one round trip.
+//
+// Each item is dispatched to routes, an internal router on which the regular
+// /api handlers are registered, so items go through exactly the same param
+// validation, rate limits and handleWithQuery cache pipeline as direct calls.
+// The caller is authenticated once; its identity is carried to each item.
+type BatchHandler struct {
+	*ProxyHandler
+	routes http.Handler
+}
+
+// NewBatchHandler creates a BatchHandler dispatching items to routes.
+func NewBatchHandler(base *ProxyHandler, routes http.Handler) *BatchHandler {
+	return &BatchHandler{ProxyHandler: base, routes: routes}
+}
+
+// Register mounts routes under /api/batch
+func (h *BatchHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("", h.batch) // POST /api/batch
+}
+
+type batchItem struct {
+	// ID is an optional client tag echoed back in the result.
+	ID string `json:"id"`
+	// Path is the API path without the /api prefix, e.g. "/recommend/banner".
+	Path  string `json:"path" binding:"required"`
+	Query string `json:"query"`
+	// ETag, when set, is sent as If-None-Match; a match yields status 304.
+	ETag string `json:"etag"`
+}
+
+type batchResult struct {
+	ID     string          `json:"id,omitempty"`
+	Path   string          `json:"path"`
+	Status int             `json:"status"`
+	ETag   string          `json:"etag,omitempty"`
+	Cache  string          `json:"x_cache,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// batch runs all items concurrently and returns their results in request order.
+//
+//	Request:  {"items": [{"id": "banner", "path": "/recommend/banner"}, {"path": "/ranking/detail", "query": "id=4"}]}
+//	Response: {"items": [{"id": "banner", "path": "...", "status": 200, "etag": "...", "x_cache": "HIT", "body": {...}}, ...]}
+func (h *BatchHandler) batch(c *gin.Context) {
+	var req struct {
+		Items []batchItem `json:"items" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+
+	limit := defaultBatchMaxItems
+	if v, err := h.cfgSvc.Get(ctx, cfgBatchMaxItems); err == nil {
+		if n, perr := strconv.Atoi(v); perr == nil && n > 0 {
+			limit = n
+		}
+	}
+	if len(req.Items) == 0 || len(req.Items) > limit {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": "items must contain 1.." + strconv.Itoa(limit) + " entries"})
+		return
+	}
+	paths := make([]string, len(req.Items))
+	for i, it := range req.Items {
+		p, ok := batchPath(it.Path)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": "path not allowed in batch: " + it.Path})
+			return
+		}
+		paths[i] = p
+	}
+
+	subCtx := proxymw.WithIdentity(ctx, c)
+	results := make([]batchResult, len(req.Items))
+	var wg sync.WaitGroup
+	for i, it := range req.Items {
+		wg.Add(1)
+		go func(i int, it batchItem) {
+			defer wg.Done()
+			// The sub-request gets the validated path, not the client's spelling.
+			target := "/api" + paths[i]
+			if it.Query != "" {
+				target += "?" + strings.TrimPrefix(it.Query, "?")
+			}
+			sub, err := http.NewRequestWithContext(subCtx, http.MethodGet, target, nil)
+			if err != nil {
+				results[i] = batchResult{ID: it.ID, Path: it.Path, Status: http.StatusBadRequest}
+				return
+			}
+			if it.ETag != "" {
+				sub.Header.Set("If-None-Match", it.ETag)
+			}
+			rec := newBatchRecorder()
+			h.routes.ServeHTTP(rec, sub)
+			results[i] = batchResult{
+				ID:     it.ID,
+				Path:   it.Path,
+				Status: rec.status,
+				ETag:   rec.header.Get("ETag"),
+				Cache:  rec.header.Get("X-Cache"),
+				Body:   rawBody(rec.body.Bytes()),
+			}
+		}(i, it)
+	}
+	wg.Wait()
+
+	c.JSON(http.StatusOK, gin.H{"items": results})
+}
+
+// batchPath validates an item path and returns it decoded. The path is
+// checked in the form gin routes on (percent-decoded), so "/song/%73tream"
+// is "/song/stream"; a path that path.Clean would change ("//", "/./",
+// "/../", a trailing slash) is refused rather than normalised.
+func batchPath(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil ||
+		u.RawQuery != "" || u.ForceQuery || u.Fragment != "" {
+		return "", false
+	}
+	p := u.Path
+	if !strings.HasPrefix(p, "/") || path.Clean(p) != p {
+		return "", false
+	}
+	return p, batchRoutes[p]
+}
+
+// rawBody embeds JSON bodies as-is and anything else (e.g. lyric SRT) as a
+// JSON string. Empty bodies (304) are omitted.
+func rawBody(b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return nil
+	}
+	if json.Valid(b) {
+		return b
+	}
+	s, _ := json.Marshal(string(b))
+	return s
+}
+
+// batchRecorder is a minimal in-memory http.ResponseWriter for sub-requests.
+type batchRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *batchRecorder) Header() http.Header         { return r.header }
+func (r *batchRecorder) Write(b []byte) (int, error) { return r.body.Write(b) }
+func (r *batchRecorder) WriteHeader(code int)        { r.status = code }
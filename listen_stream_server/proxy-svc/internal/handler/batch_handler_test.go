@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestBatchPath(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"/song/detail", "/song/detail", true},
+		{"/search/songs", "/search/songs", true},
+		{"/se%61rch/songs", "/search/songs", true},
+		{"/song/stream", "", false},
+		{"/song/%73tream", "", false},
+		{"/song/%2573tream", "", false},
+		{"/batch", "", false},
+		{"/b%61tch", "", false},
+		{"/song/../song/stream", "", false},
+		{"/song/./detail", "", false},
+		{"//song/detail", "", false},
+		{"/song/detail/", "", false},
+		{"/song/detail?id=1", "", false},
+		{"/song/detail?", "", false},
+		{"/song/detail#x", "", false},
+		{"song/detail", "", false},
+		{"http://evil/song/detail", "", false},
+		{"/song/%zz", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := batchPath(tc.raw)
+		if ok != tc.ok || (ok && got != tc.want) {
+			t.Errorf("batchPath(%q) = %q, %v; want %q, %v", tc.raw, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+// TestBatchRoutesRegistered keeps the allowlist in step with the router: an
+// entry for a route that does not exist would only ever answer 404.
+func TestBatchRoutesRegistered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base := &ProxyHandler{log: zap.NewNop()}
+	r := gin.New()
+	NewRecommendHandler(base).Register(r.Group("/recommend"))
+	NewPlaylistHandler(base).Register(r.Group("/playlist"))
+	NewSingerHandler(base).Register(r.Group("/artist"))
+	NewRankingHandler(base).Register(r.Group("/ranking"))
+	NewRadioHandler(base).Register(r.Group("/radio"))
+	NewMVHandler(base).Register(r.Group("/mv"))
+	NewAlbumHandler(base).Register(r.Group("/album"))
+	NewSearchHandler(base).Register(r.Group("/search"))
+	NewLyricHandler(base).Register(r.Group("/lyric"))
+	NewSongHandler(base, nil).Register(r.Group("/song"), r.Group("/song"))
+
+	registered := map[string]bool{}
+	for _, rt := range r.Routes() {
+		if rt.Method == "GET" {
+			registered[rt.Path] = true
+		}
+	}
+	for p := range batchRoutes {
+		if !registered[p] {
+			t.Errorf("batch allowlist has %s, which no handler registers", p)
+		}
+	}
+	if !registered["/song/stream"] {
+		t.Fatal("expected /song/stream to be registered")
+	}
+}
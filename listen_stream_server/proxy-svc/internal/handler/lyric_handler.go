@@ -2,10 +2,13 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
-	pxcfg "listen-stream/proxy-svc/internal/config"
+	"listen-stream/proxy-svc/internal/catalog"
+	"listen-stream/proxy-svc/internal/lyric"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // LyricHandler serves /api/lyric endpoint.
@@ -15,14 +18,73 @@ type LyricHandler struct{ *ProxyHandler }
 func NewLyricHandler(base *ProxyHandler) *LyricHandler { return &LyricHandler{base} }
 
 func (h *LyricHandler) Register(rg *gin.RouterGroup) {
-	rg.GET("", h.lyric)  // GET /api/lyric?id=...
+	rg.GET("", h.lyric) // GET /api/lyric?id=...&format=json|lrc|srt|vtt
 }
 
 // lyric requires the song id query param.
+//
+// Without format (or with format=normalized) the upstream payload is proxied
+// as-is. format=json|lrc|srt|vtt parses the LRC, merges the translation by
+// timestamp and renders it server-side; the cache still holds the raw upstream
+// body, so all formats share one cache entry.
 func (h *LyricHandler) lyric(c *gin.Context) {
 	if c.Query("id") == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_PARAM", "message": "id is required"})
 		return
 	}
-	h.handle(c, "/lyric/", pxcfg.ProxyTTL["/lyric"])
+	format := c.Query("format")
+	switch format {
+	case "", catalog.FormatNormalized:
+		h.handle(c, "/lyric/")
+		return
+	case lyric.FormatJSON, lyric.FormatLRC, lyric.FormatSRT, lyric.FormatVTT:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAM", "message": "format must be one of json, lrc, srt, vtt"})
+		return
+	}
+
+	// Drop format the same way stripNormalizedFormat does for the raw path, so
+	// the upstream query, and so the cache key, is identical for every format.
+	rawQuery := stripQueryParam(c.Request.URL.RawQuery, "format")
+
+	start := time.Now()
+	entry, status, err := h.load(c.Request.Context(), "/lyric/", rawQuery)
+	defer func() { h.record(c, "/lyric/", rawQuery, entry, status, start) }()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"code": "UPSTREAM_ERROR", "message": "upstream unavailable"})
+		return
+	}
+	etag := variantETag(entry.ETag, format)
+	if etagMatch(c.GetHeader("If-None-Match"), etag) {
+		writeEntry(c, etag, status, lyric.ContentType(format), nil)
+		return
+	}
+
+	body, err := entry.Body()
+	if err != nil {
+		h.log.Error("cached lyric unreadable", zap.String("id", c.Query("id")), zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"code": "UPSTREAM_ERROR", "message": "upstream unavailable"})
+		return
+	}
+	v, err := catalog.Translate("/lyric/", rawQuery, body)
+	if err != nil {
+		h.log.Warn("lyric decode failed", zap.String("id", c.Query("id")), zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"code": "UPSTREAM_ERROR", "message": err.Error()})
+		return
+	}
+	raw := v.(catalog.Lyric)
+	orig := lyric.Parse(raw.LRC)
+	var trans *lyric.Document
+	if raw.Translation != "" {
+		trans = lyric.Parse(raw.Translation)
+	}
+	cues := lyric.Merge(orig, trans)
+
+	if format == lyric.FormatJSON {
+		c.Header("ETag", etag)
+		c.Header("X-Cache", status)
+		c.JSON(http.StatusOK, gin.H{"data": lyric.ToJSON(orig.Meta, cues)})
+		return
+	}
+	writeEntry(c, etag, status, lyric.ContentType(format), []byte(lyric.Render(format, orig.Meta, cues)))
 }
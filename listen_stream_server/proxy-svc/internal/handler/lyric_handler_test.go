@@ -0,0 +1,14 @@
+package handler
+
+import "testing"
+
+func TestLyricFormatSharesCacheKey(t *testing.T) {
+	raw := "id=%E6%99%B4&format=srt&x=1"
+	stripped := stripQueryParam(raw, "format")
+	if stripped != "id=%E6%99%B4&x=1" {
+		t.Fatalf("stripQueryParam = %q", stripped)
+	}
+	if buildCacheKey("/lyric/", stripped) != buildCacheKey("/lyric/", "id=%E6%99%B4&x=1") {
+		t.Error("format=srt and the raw lyric request use different cache keys")
+	}
+}
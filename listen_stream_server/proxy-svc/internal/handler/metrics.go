@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"listen-stream/shared/pkg/metrics"
+)
+
+// Proxy metrics. Paths are upstream paths ("/search/", "/lyric/"), a fixed set.
+var (
+	// proxyRequests counts requests served through load by X-Cache status
+	// (HIT, MISS, STALE, STALE-REVALIDATING, ERROR); the hit ratio is
+	// HIT + STALE-REVALIDATING over the total.
+	proxyRequests = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "proxy",
+		Name:      "cache_requests_total",
+		Help:      "Proxied requests by upstream path and cache status.",
+	}, []string{"path", "status"})
+
+	// proxyTiers counts which cache tier (memory, redis, upstream) served a response.
+	proxyTiers = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "proxy",
+		Name:      "cache_tier_total",
+		Help:      "Proxied responses by the tier that served them.",
+	}, []string{"tier"})
+
+	// upstreamDuration times upstream fetches, including endpoint failover
+	// and retries inside upstream.Client.
+	upstreamDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "proxy",
+		Name:      "upstream_duration_seconds",
+		Help:      "Upstream fetch latency by upstream path and result (ok, error).",
+		Buckets:   []float64{.025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"path", "result"})
+)
@@ -1,8 +1,6 @@
 package handler
 
 import (
-	pxcfg "listen-stream/proxy-svc/internal/config"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,12 +17,12 @@ func (h *MVHandler) Register(rg *gin.RouterGroup) {
 }
 
 func (h *MVHandler) category(c *gin.Context) {
-	h.handle(c, "/mv/category", pxcfg.ProxyTTL["/mv/category"])
+	h.handle(c, "/mv/category")
 }
 func (h *MVHandler) list(c *gin.Context) {
-	h.handle(c, "/mv/list", pxcfg.ProxyTTL["/mv/list"])
+	h.handle(c, "/mv/list")
 }
 func (h *MVHandler) detail(c *gin.Context) {
 	// Client sends 'vid', upstream expects 'id'
-	h.handleWithParamMap(c, "/mv/detail", pxcfg.ProxyTTL["/mv/detail"], map[string]string{"vid": "id"}) // TTL == 0
+	h.handleWithParamMap(c, "/mv/detail", map[string]string{"vid": "id"}) // TTL == 0
 }
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
-	pxcfg "listen-stream/proxy-svc/internal/config"
 )
 
 // PlaylistHandler serves /api/playlist/* endpoints.
@@ -17,11 +16,11 @@ func (h *PlaylistHandler) Register(rg *gin.RouterGroup) {
 }
 
 func (h *PlaylistHandler) category(c *gin.Context) {
-	h.handle(c, "/playlist/category", pxcfg.ProxyTTL["/playlist/category"])
+	h.handle(c, "/playlist/category")
 }
 func (h *PlaylistHandler) information(c *gin.Context) {
-	h.handle(c, "/playlist/information", pxcfg.ProxyTTL["/playlist/information"])
+	h.handle(c, "/playlist/information")
 }
 func (h *PlaylistHandler) detail(c *gin.Context) {
-	h.handle(c, "/playlist/detail", pxcfg.ProxyTTL["/playlist/detail"])
+	h.handle(c, "/playlist/detail")
 }
@@ -2,41 +2,92 @@
 package handler
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 
+	"listen-stream/proxy-svc/internal/accesslog"
 	"listen-stream/proxy-svc/internal/cache"
+	"listen-stream/proxy-svc/internal/catalog"
+	pxcfg "listen-stream/proxy-svc/internal/config"
 	"listen-stream/proxy-svc/internal/upstream"
+	"listen-stream/shared/pkg/config"
 	"listen-stream/shared/pkg/rdb"
 )
 
+const (
+	// fetchTimeout bounds a coalesced upstream fetch. It is detached from the
+	// request context so one client disconnecting does not fail its followers.
+	fetchTimeout = 30 * time.Second
+	// fillWaitTimeout is how long a replica waits for another replica's cache
+	// fill before calling upstream itself.
+	fillWaitTimeout = 10 * time.Second
+
+	// cfgSoftTTLRatio is the fraction of an endpoint's TTL after which a hit is
+	// served as STALE-REVALIDATING and refreshed in the background.
+	// "0" disables stale-while-revalidate.
+	cfgSoftTTLRatio     = "PROXY_SOFT_TTL_RATIO"
+	defaultSoftTTLRatio = 0.8
+
+	// cfgMemoryCacheMB is the size of the in-process cache tier in MiB, read at
+	// startup. "0" disables the tier (every hit goes to Redis).
+	cfgMemoryCacheMB     = "PROXY_MEMORY_CACHE_MB"
+	defaultMemoryCacheMB = 64
+
+	// revalidateWorkers / revalidateQueue bound background refresh concurrency.
+	revalidateWorkers = 4
+	revalidateQueue   = 256
+)
+
 // ProxyHandler is the central proxy dispatcher.
 // Each route handler embeds *ProxyHandler and delegates to handle().
 type ProxyHandler struct {
-	client *upstream.Client
-	cache  *cache.ProxyCache
-	log    *zap.Logger
+	client      *upstream.Client
+	cache       *cache.ProxyCache
+	revalidator *cache.Revalidator
+	stats       *cache.Stats
+	ttls        *pxcfg.TTLs
+	cfgSvc      config.Service
+	accessLog   *accesslog.Writer
+	log         *zap.Logger
+	flight      singleflight.Group // coalesces concurrent misses per cache key
 }
 
 // NewProxyHandler creates a ProxyHandler ready to serve requests.
-func NewProxyHandler(client *upstream.Client, rdbClient *rdb.Client, log *zap.Logger) *ProxyHandler {
+// Every proxied call is recorded to accessLog (proxy_logs) asynchronously.
+func NewProxyHandler(client *upstream.Client, rdbClient *rdb.Client, cfgSvc config.Service, accessLog *accesslog.Writer, log *zap.Logger) *ProxyHandler {
+	memoryMB := defaultMemoryCacheMB
+	if v, err := cfgSvc.Get(context.Background(), cfgMemoryCacheMB); err == nil {
+		if n, perr := strconv.Atoi(v); perr == nil && n >= 0 {
+			memoryMB = n
+		}
+	}
 	return &ProxyHandler{
-		client: client,
-		cache:  cache.NewProxyCache(rdbClient),
-		log:    log,
+		client:      client,
+		cache:       cache.NewProxyCache(rdbClient, int64(memoryMB)<<20, log),
+		revalidator: cache.NewRevalidator(revalidateWorkers, revalidateQueue, log),
+		stats:       cache.NewStats(rdbClient, log),
+		ttls:        pxcfg.NewTTLs(cfgSvc, log),
+		cfgSvc:      cfgSvc,
+		accessLog:   accessLog,
+		log:         log,
 	}
 }
 
 // handleWithParamMap handles requests with parameter name mapping (e.g., mid → id).
 // paramMap is a map of {clientParamName: upstreamParamName}.
-func (h *ProxyHandler) handleWithParamMap(c *gin.Context, upstreamPath string, ttl time.Duration, paramMap map[string]string) {
+func (h *ProxyHandler) handleWithParamMap(c *gin.Context, upstreamPath string, paramMap map[string]string) {
 	// Build remapped query string
 	values := make(map[string]string)
 	for clientKey, upstreamKey := range paramMap {
@@ -59,7 +110,7 @@ func (h *ProxyHandler) handleWithParamMap(c *gin.Context, upstreamPath string, t
 	sort.Strings(parts)
 	remappedQuery := strings.Join(parts, "&")
 	
-	h.handleWithQuery(c, upstreamPath, ttl, remappedQuery)
+	h.handleWithQuery(c, upstreamPath, remappedQuery)
 }
 
 // handle is the single dispatch point: cache lookup → upstream → cache write.
@@ -67,17 +118,94 @@ func (h *ProxyHandler) handleWithParamMap(c *gin.Context, upstreamPath string, t
 //  1. Normalise query params (sort keys + values alphabetically) and SHA-256
 //     hash them to build a stable cache key.
 //  2. Cache HIT  → set ETag header; return 304 on If-None-Match match, else 200.
+//     A hit older than the soft TTL (a fraction of the TTL) is served as
+//     X-Cache: STALE-REVALIDATING and refreshed by a background worker. Once
+//     the TTL itself has passed the entry is a miss.
 //  3. Cache MISS with ttl > 0 → call upstream, cache response, return 200.
+//     Concurrent misses on the same key share one upstream call (see fetch).
 //  4. Cache MISS with ttl == 0 → forward directly, no cache write.
 //  5. Upstream failure on a cached (possibly stale) path → return stale copy
 //     with X-Cache: STALE header rather than propagating a 5xx.
-func (h *ProxyHandler) handle(c *gin.Context, upstreamPath string, ttl time.Duration) {
-	h.handleWithQuery(c, upstreamPath, ttl, c.Request.URL.RawQuery)
+//
+// The TTL is looked up by upstreamPath in the proxyttl table (see ttl).
+//
+// With ?format=normalized the raw body (cached or fresh) is translated into
+// the catalog schema just before it is written; the cache always holds the
+// raw upstream body, so both formats share one cache entry.
+func (h *ProxyHandler) handle(c *gin.Context, upstreamPath string) {
+	h.handleWithQuery(c, upstreamPath, c.Request.URL.RawQuery)
 }
 
 // handleWithQuery is the internal handler that accepts a custom query string.
-func (h *ProxyHandler) handleWithQuery(c *gin.Context, upstreamPath string, ttl time.Duration, rawQuery string) {
+func (h *ProxyHandler) handleWithQuery(c *gin.Context, upstreamPath, rawQuery string) {
 	ctx := c.Request.Context()
+	start := time.Now()
+
+	rawQuery, normalized := stripNormalizedFormat(rawQuery)
+	if normalized && !catalog.Supported(upstreamPath) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "NORMALIZE_UNSUPPORTED", "message": "format=normalized is not supported for this endpoint"})
+		return
+	}
+
+	entry, status, err := h.load(ctx, upstreamPath, rawQuery)
+	defer func() { h.record(c, upstreamPath, rawQuery, entry, status, start) }()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"code": "UPSTREAM_ERROR", "message": "upstream unavailable"})
+		return
+	}
+	h.respond(c, upstreamPath, rawQuery, entry, status, normalized)
+}
+
+// Start applies cache invalidations broadcast by other replicas to the
+// in-process tier until ctx is cancelled.
+func (h *ProxyHandler) Start(ctx context.Context) {
+	go h.cache.Run(ctx)
+}
+
+// Close flushes the cache outcome counters. Call on shutdown.
+func (h *ProxyHandler) Close() {
+	h.stats.Close()
+}
+
+// record counts the cache outcome and serving tier of a request served from
+// load and queues its proxy_logs row. entry is nil and status empty when load
+// failed; upstream is only known on a MISS.
+func (h *ProxyHandler) record(c *gin.Context, upstreamPath, rawQuery string, entry *cache.Entry, status string, start time.Time) {
+	if entry == nil {
+		h.stats.Count(upstreamPath, "ERROR")
+		proxyRequests.WithLabelValues(upstreamPath, "ERROR").Inc()
+	} else {
+		h.stats.Count(upstreamPath, status)
+		h.stats.CountTier(entry.Tier)
+		proxyRequests.WithLabelValues(upstreamPath, status).Inc()
+		proxyTiers.WithLabelValues(entry.Tier).Inc()
+	}
+	if h.accessLog == nil {
+		return
+	}
+	r := accesslog.Record{
+		Time:        start,
+		UserID:      c.GetString("user_id"),
+		Path:        upstreamPath,
+		QueryHash:   queryHash(upstreamPath, rawQuery),
+		CacheStatus: status,
+		Status:      c.Writer.Status(),
+		Latency:     time.Since(start),
+	}
+	if entry == nil {
+		r.CacheStatus = "ERROR"
+	} else if status == "MISS" {
+		r.Upstream = entry.Upstream
+	}
+	h.accessLog.Log(r)
+}
+
+// load runs steps 1–5 of handle without writing a response: it returns the
+// raw upstream entry (cached, fresh or stale) and its X-Cache status.
+// Handlers that post-process the upstream body (lyric rendering, batch) use
+// it directly so they share the cache, coalescing and stale fallback.
+func (h *ProxyHandler) load(ctx context.Context, upstreamPath, rawQuery string) (*cache.Entry, string, error) {
+	ttl := h.ttl(ctx, upstreamPath)
 
 	// ── 1. Build cache key ───────────────────────────────────────────────────
 	cacheKey := ""
@@ -87,21 +215,17 @@ func (h *ProxyHandler) handleWithQuery(c *gin.Context, upstreamPath string, ttl
 
 	// ── 2. Cache lookup ──────────────────────────────────────────────────────
 	if ttl > 0 {
-		entry, err := h.cache.Get(ctx, cacheKey)
-		if err == nil && entry != nil {
-			c.Header("ETag", entry.ETag)
-			c.Header("X-Cache", "HIT")
-			if c.GetHeader("If-None-Match") == entry.ETag {
-				c.Status(http.StatusNotModified)
-				return
+		entry, status := h.lookup(ctx, cacheKey, ttl)
+		if entry != nil {
+			if status == "STALE-REVALIDATING" {
+				h.revalidate(upstreamPath, rawQuery, cacheKey, ttl)
 			}
-			c.Data(http.StatusOK, "application/json; charset=utf-8", entry.Body)
-			return
+			return entry, status, nil
 		}
 	}
 
 	// ── 3 / 4. Call upstream ─────────────────────────────────────────────────
-	body, err := h.client.Do(ctx, upstreamPath, rawQuery)
+	entry, err := h.fetch(ctx, upstreamPath, rawQuery, cacheKey, ttl)
 	if err != nil {
 		// ── 5. Stale fallback ─────────────────────────────────────────────
 		if ttl > 0 {
@@ -109,46 +233,260 @@ func (h *ProxyHandler) handleWithQuery(c *gin.Context, upstreamPath string, ttl
 			if serr == nil && stale != nil {
 				h.log.Warn("upstream error, serving stale cache",
 					zap.String("path", upstreamPath), zap.Error(err))
-				c.Header("ETag", stale.ETag)
-				c.Header("X-Cache", "STALE")
-				c.Data(http.StatusOK, "application/json; charset=utf-8", stale.Body)
-				return
+				return stale, "STALE", nil
 			}
 		}
 		h.log.Error("upstream error", zap.String("path", upstreamPath), zap.Error(err))
+		return nil, "", err
+	}
+	return entry, "MISS", nil
+}
+
+// ttl returns the cache TTL for upstreamPath: its proxyttl default, or the
+// PROXY_TTLS override. A path missing from the table is logged and not cached.
+func (h *ProxyHandler) ttl(ctx context.Context, upstreamPath string) time.Duration {
+	ttl, _ := h.ttls.Get(ctx, upstreamPath)
+	return ttl
+}
+
+// respond writes entry with its ETag and X-Cache status, answering 304 when
+// If-None-Match matches. In normalized mode the body is translated through
+// catalog.Translate and the ETag gets a "-n" suffix so the two representations
+// never validate against each other.
+//
+// Raw responses are content-negotiated (Vary: Accept-Encoding): a client
+// sending Accept-Encoding: gzip gets the compressed bytes stored in the cache
+// as-is, under a "-gzip" variant ETag; others get the body decompressed under
+// the entry's own ETag. An If-None-Match therefore only validates the
+// encoding it was issued for. Only gzip is offered: entries are stored
+// gzipped, and any other coding would mean re-encoding every response.
+func (h *ProxyHandler) respond(c *gin.Context, upstreamPath, rawQuery string, entry *cache.Entry, status string, normalized bool) {
+	if !normalized {
+		c.Header("Vary", "Accept-Encoding")
+		if gz := entry.Gzip(); gz != nil && acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Header("Content-Encoding", "gzip")
+			writeEntry(c, variantETag(entry.ETag, "gzip"), status, "application/json; charset=utf-8", gz)
+			return
+		}
+	}
+
+	body, err := entry.Body()
+	if err != nil {
+		h.log.Error("cached body unreadable", zap.String("path", upstreamPath), zap.Error(err))
 		c.JSON(http.StatusBadGateway, gin.H{"code": "UPSTREAM_ERROR", "message": "upstream unavailable"})
 		return
 	}
+	etag := entry.ETag
+	if normalized {
+		v, err := catalog.Translate(upstreamPath, rawQuery, body)
+		if err != nil {
+			h.log.Warn("normalize failed", zap.String("path", upstreamPath), zap.Error(err))
+			code := "NORMALIZE_FAILED"
+			if errors.Is(err, catalog.ErrUpstreamCode) {
+				code = "UPSTREAM_ERROR"
+			}
+			c.JSON(http.StatusBadGateway, gin.H{"code": code, "message": err.Error()})
+			return
+		}
+		if body, err = json.Marshal(gin.H{"data": v}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": "INTERNAL_ERROR", "message": err.Error()})
+			return
+		}
+		etag = variantETag(etag, "n")
+	}
+	writeEntry(c, etag, status, "application/json; charset=utf-8", body)
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+// (a listed "gzip" or "*" without q=0).
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(v, 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// writeEntry writes body with its ETag and X-Cache status, answering 304 when
+// If-None-Match matches.
+func writeEntry(c *gin.Context, etag, status, contentType string, body []byte) {
+	c.Header("ETag", etag)
+	c.Header("X-Cache", status)
+	if etagMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// etagMatch reports whether an If-None-Match header lists etag, using the
+// weak comparison RFC 9110 prescribes for If-None-Match.
+func etagMatch(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// variantETag derives the ETag of a representation rendered from the raw
+// entry, e.g. `"abc"` + "n" → `"abc-n"`.
+func variantETag(etag, variant string) string {
+	return strings.TrimSuffix(etag, `"`) + "-" + variant + `"`
+}
+
+// lookup returns the live cached entry for cacheKey and its X-Cache status:
+// "HIT" while younger than the soft TTL, "STALE-REVALIDATING" from then until
+// the live key expires at ttl. A nil entry is a miss; the stale copy is only
+// served by load when upstream fails.
+func (h *ProxyHandler) lookup(ctx context.Context, cacheKey string, ttl time.Duration) (*cache.Entry, string) {
+	entry, err := h.cache.Get(ctx, cacheKey)
+	if err != nil || entry == nil {
+		return nil, ""
+	}
+	if soft := h.softTTL(ctx, ttl); soft > 0 && soft < ttl && entry.Age() >= soft {
+		return entry, "STALE-REVALIDATING"
+	}
+	return entry, "HIT"
+}
+
+// softTTL returns ttl × PROXY_SOFT_TTL_RATIO, or 0 when revalidation is
+// disabled. A ratio of 1 is the same as 0: the entry expires before it would
+// be revalidated.
+func (h *ProxyHandler) softTTL(ctx context.Context, ttl time.Duration) time.Duration {
+	ratio := defaultSoftTTLRatio
+	if v, err := h.cfgSvc.Get(ctx, cfgSoftTTLRatio); err == nil && v != "" {
+		if f, perr := strconv.ParseFloat(v, 64); perr == nil && f >= 0 && f <= 1 {
+			ratio = f
+		}
+	}
+	return time.Duration(float64(ttl) * ratio)
+}
+
+// revalidate queues a background refresh of cacheKey. The refresh goes
+// through fetch, so it is coalesced with any foreground miss on the same key.
+func (h *ProxyHandler) revalidate(upstreamPath, rawQuery, cacheKey string, ttl time.Duration) {
+	h.revalidator.Submit(cacheKey, func(ctx context.Context) {
+		if _, err := h.fetch(ctx, upstreamPath, rawQuery, cacheKey, ttl); err != nil {
+			h.log.Warn("background revalidate failed",
+				zap.String("path", upstreamPath), zap.Error(err))
+		}
+	})
+}
+
+// fetch returns a fresh entry for (upstreamPath, rawQuery).
+//
+// Uncached paths (cacheKey == "") go straight to upstream. For cached paths,
+// concurrent misses are coalesced twice over:
+//   - in-process, singleflight lets one goroutine per cache key call upstream;
+//   - across replicas, a Redis fill lock lets one instance call upstream while
+//     the others poll the cache until the fill lands (or fillWaitTimeout).
+func (h *ProxyHandler) fetch(ctx context.Context, upstreamPath, rawQuery, cacheKey string, ttl time.Duration) (*cache.Entry, error) {
+	if cacheKey == "" {
+		return h.fetchUpstream(ctx, upstreamPath, rawQuery, "", 0)
+	}
+
+	ch := h.flight.DoChan(cacheKey, func() (interface{}, error) {
+		fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+
+		release, acquired := h.cache.AcquireFill(fctx, cacheKey)
+		if acquired {
+			defer release()
+		} else if e := h.cache.WaitFill(fctx, cacheKey, fillWaitTimeout); e != nil {
+			return e, nil
+		}
+		return h.fetchUpstream(fctx, upstreamPath, rawQuery, cacheKey, ttl)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.(*cache.Entry), nil
+	}
+}
+
+// fetchUpstream calls upstream, derives the ETag and writes the cache when ttl > 0.
+func (h *ProxyHandler) fetchUpstream(ctx context.Context, upstreamPath, rawQuery, cacheKey string, ttl time.Duration) (*cache.Entry, error) {
+	tctx, trace := upstream.WithTrace(ctx)
+	start := time.Now()
+	body, err := h.client.Do(tctx, upstreamPath, rawQuery)
+	if err != nil {
+		upstreamDuration.WithLabelValues(upstreamPath, "error").Observe(time.Since(start).Seconds())
+		return nil, err
+	}
+	upstreamDuration.WithLabelValues(upstreamPath, "ok").Observe(time.Since(start).Seconds())
 
 	// ── ETag from body SHA-256 prefix ────────────────────────────────────────
 	sum := sha256.Sum256(body)
-	etag := fmt.Sprintf(`"%x"`, sum[:8])
+	entry := cache.NewEntry(body, fmt.Sprintf(`"%x"`, sum[:8]), trace.BaseURL)
 
 	// ── Write cache ──────────────────────────────────────────────────────────
 	if ttl > 0 {
-		entry := &cache.Entry{Body: body, ETag: etag}
 		_ = h.cache.Set(ctx, cacheKey, entry, ttl)
 	}
-
-	c.Header("ETag", etag)
-	c.Header("X-Cache", "MISS")
-	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	return entry, nil
 }
 
 // buildCacheKey returns a stable Redis key for (upstreamPath, rawQuery).
 // Query parameters are sorted so ?a=1&b=2 and ?b=2&a=1 hash identically.
 func buildCacheKey(upstreamPath, rawQuery string) string {
-	normalised := normaliseQuery(rawQuery)
-	h := sha256.Sum256([]byte(upstreamPath + "?" + normalised))
-	return rdb.KeyProxyCache(upstreamPath, fmt.Sprintf("%x", h[:8]))
+	return rdb.KeyProxyCache(upstreamPath, queryHash(upstreamPath, rawQuery))
+}
+
+// queryHash identifies a request in both the cache key and proxy_logs.
+// admin-svc computes the same hash to purge a single query.
+func queryHash(upstreamPath, rawQuery string) string {
+	return rdb.ProxyQueryHash(upstreamPath, rawQuery)
 }
 
-// normaliseQuery sorts query parameters for cache key stability.
-func normaliseQuery(rawQuery string) string {
+// stripQueryParam removes every key=… (or bare key) pair from rawQuery,
+// leaving the other pairs untouched and in order.
+func stripQueryParam(rawQuery, key string) string {
 	if rawQuery == "" {
 		return ""
 	}
 	parts := strings.Split(rawQuery, "&")
-	sort.Strings(parts)
-	return strings.Join(parts, "&")
+	kept := parts[:0]
+	for _, p := range parts {
+		if k, _, _ := strings.Cut(p, "="); k == key {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return strings.Join(kept, "&")
+}
+
+// stripNormalizedFormat removes format=normalized from rawQuery so it never
+// reaches upstream or the cache key, and reports whether it was present.
+func stripNormalizedFormat(rawQuery string) (string, bool) {
+	if rawQuery == "" {
+		return "", false
+	}
+	found := false
+	parts := strings.Split(rawQuery, "&")
+	kept := parts[:0]
+	for _, p := range parts {
+		if p == "format="+catalog.FormatNormalized {
+			found = true
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return strings.Join(kept, "&"), found
 }
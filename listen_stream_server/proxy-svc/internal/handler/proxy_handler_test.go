@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestETagMatch(t *testing.T) {
+	const etag = `"abc"`
+	gz := variantETag(etag, "gzip")
+	cases := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"x", "abc"`, true},
+		{`*`, true},
+		{``, false},
+		{gz, false}, // the gzip ETag does not validate the identity body
+		{`"abc-n"`, false},
+	}
+	for _, tc := range cases {
+		if got := etagMatch(tc.header, etag); got != tc.want {
+			t.Errorf("etagMatch(%q, %q) = %v, want %v", tc.header, etag, got, tc.want)
+		}
+	}
+	if etagMatch(etag, gz) {
+		t.Errorf("identity ETag validates the gzip variant %s", gz)
+	}
+}
+
+func TestWriteEntryNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		writeEntry(c, `"abc-gzip"`, "HIT", "application/json", []byte(`{}`))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"old", "abc-gzip"`)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", w.Code)
+	}
+	if got := w.Header().Get("ETag"); got != `"abc-gzip"` {
+		t.Errorf("ETag = %s", got)
+	}
+}
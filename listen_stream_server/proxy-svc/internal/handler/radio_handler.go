@@ -2,7 +2,6 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
-	pxcfg "listen-stream/proxy-svc/internal/config"
 )
 
 // RadioHandler serves /api/radio/* endpoints.
@@ -17,8 +16,8 @@ func (h *RadioHandler) Register(rg *gin.RouterGroup) {
 }
 
 func (h *RadioHandler) category(c *gin.Context) {
-	h.handle(c, "/radio/category", pxcfg.ProxyTTL["/radio/category"])
+	h.handle(c, "/radio/category")
 }
 func (h *RadioHandler) songlist(c *gin.Context) {
-	h.handle(c, "/radio/songlist", pxcfg.ProxyTTL["/radio/songlist"]) // TTL == 0
+	h.handle(c, "/radio/songlist") // TTL == 0
 }
@@ -1,8 +1,6 @@
 package handler
 
 import (
-	pxcfg "listen-stream/proxy-svc/internal/config"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,8 +15,8 @@ func (h *RankingHandler) Register(rg *gin.RouterGroup) {
 }
 
 func (h *RankingHandler) list(c *gin.Context) {
-	h.handle(c, "/rankings/list", pxcfg.ProxyTTL["/rankings/list"])
+	h.handle(c, "/rankings/list")
 }
 func (h *RankingHandler) detail(c *gin.Context) {
-	h.handleWithParamMap(c, "/rankings/detail", pxcfg.ProxyTTL["/rankings/detail"], map[string]string{"id": "id"})
+	h.handleWithParamMap(c, "/rankings/detail", map[string]string{"id": "id"})
 }
@@ -1,8 +1,6 @@
 package handler
 
 import (
-	pxcfg "listen-stream/proxy-svc/internal/config"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,17 +22,17 @@ func (h *RecommendHandler) Register(rg *gin.RouterGroup) {
 }
 
 func (h *RecommendHandler) banner(c *gin.Context) {
-	h.handle(c, "/recommend/banner", pxcfg.ProxyTTL["/recommend/banner"])
+	h.handle(c, "/recommend/banner")
 }
 func (h *RecommendHandler) daily(c *gin.Context) {
-	h.handle(c, "/recommend/daily", pxcfg.ProxyTTL["/recommend/daily"])
+	h.handle(c, "/recommend/daily")
 }
 func (h *RecommendHandler) playlist(c *gin.Context) {
-	h.handle(c, "/recommend/playlist", pxcfg.ProxyTTL["/recommend/playlist"])
+	h.handle(c, "/recommend/playlist")
 }
 func (h *RecommendHandler) newSongs(c *gin.Context) {
-	h.handle(c, "/recommend/new/songs", pxcfg.ProxyTTL["/recommend/new-songs"])
+	h.handle(c, "/recommend/new/songs")
 }
 func (h *RecommendHandler) newAlbums(c *gin.Context) {
-	h.handle(c, "/recommend/new/albums", pxcfg.ProxyTTL["/recommend/new-albums"])
+	h.handle(c, "/recommend/new/albums")
 }
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"listen-stream/proxy-svc/internal/cache"
+	"listen-stream/proxy-svc/internal/catalog"
+)
+
+const (
+	// cfgSearchAllLimits is a JSON object of per-type result limits for
+	// /api/search/all, e.g. {"songs":10,"singers":3}. Missing types keep their default.
+	cfgSearchAllLimits = "SEARCH_ALL_LIMITS"
+	maxSearchAllLimit  = 30
+)
+
+// searchType is one result type /api/search/all fans out to.
+type searchType struct {
+	name     string // section name in the response and ?types=
+	code     string // upstream /search/ type
+	topLabel string // "type" of the top result when it comes from this section
+	limit    int    // default number of items
+}
+
+// searchTypes in default section order; ties in ranking keep this order.
+var searchTypes = []searchType{
+	{name: "singers", code: "9", topLabel: "singer", limit: 3},
+	{name: "songs", code: "0", topLabel: "song", limit: 10},
+	{name: "albums", code: "8", topLabel: "album", limit: 6},
+	{name: "mvs", code: "12", topLabel: "mv", limit: 6},
+}
+
+// searchSection is one type's slice of the merged response.
+type searchSection struct {
+	Type  string          `json:"type"`
+	Total int             `json:"total"`
+	Items json.RawMessage `json:"items"`
+	Cache string          `json:"x_cache"`
+
+	score float64 // best item's match against the keyword; orders sections
+	top   interface{}
+}
+
+// searchAll runs the song, singer, album and MV searches concurrently through
+// load and merges them into one response. Each type is cached under
+// keyword=…&page=1&size=<limit>&type=<code>: identical /search/all calls
+// share it, but a per-type request only does when its raw query encodes to
+// exactly that, since cache keys hash the query as sent.
+//
+// Query params: keyword or q (required); types (optional comma list of
+// singers, songs, albums, mvs; default all). Per-type limits come from
+// SEARCH_ALL_LIMITS.
+//
+// A failed type is reported under "errors" and the rest are still returned;
+// only when every type fails is the answer 502. Sections are ranked by how
+// well their best item matches the keyword, and that best item across all
+// sections is returned as "top":
+//
+//	{"keyword": "...", "top": {"type": "singer", "item": {...}},
+//	 "sections": [{"type": "singers", "total": 12, "items": [...], "x_cache": "HIT"}, ...],
+//	 "errors": {"mvs": "upstream unavailable"}}
+func (h *SearchHandler) searchAll(c *gin.Context) {
+	keyword := c.Query("keyword")
+	if keyword == "" {
+		keyword = c.Query("q")
+	}
+	if keyword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_PARAM", "message": "keyword or q is required"})
+		return
+	}
+	types, err := selectSearchTypes(c.Query("types"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+	limits := h.searchLimits(ctx)
+
+	type outcome struct {
+		rawQuery string
+		entry    *cache.Entry
+		status   string
+		start    time.Time
+		section  *searchSection
+		err      string
+	}
+	outcomes := make([]outcome, len(types))
+	var wg sync.WaitGroup
+	for i, t := range types {
+		wg.Add(1)
+		go func(i int, t searchType) {
+			defer wg.Done()
+			o := &outcomes[i]
+			o.start = time.Now()
+			o.rawQuery = url.Values{
+				"keyword": {keyword},
+				"type":    {t.code},
+				"page":    {"1"},
+				"size":    {strconv.Itoa(limits[t.name])},
+			}.Encode()
+			var err error
+			o.entry, o.status, err = h.load(ctx, "/search/", o.rawQuery)
+			if err != nil {
+				o.err = "upstream unavailable"
+				return
+			}
+			if o.section, err = searchSectionOf(t, keyword, o.rawQuery, o.entry, limits[t.name]); err != nil {
+				o.err = err.Error()
+				return
+			}
+			o.section.Cache = o.status
+		}(i, t)
+	}
+	wg.Wait()
+
+	var (
+		sections []*searchSection
+		top      *searchSection
+		topType  string
+	)
+	errs := make(map[string]string)
+	for i, o := range outcomes {
+		if o.err != "" {
+			errs[types[i].name] = o.err
+			continue
+		}
+		sections = append(sections, o.section)
+		if o.section.top != nil && (top == nil || o.section.score > top.score) {
+			top, topType = o.section, types[i].topLabel
+		}
+	}
+	// Stable: equal scores keep searchTypes order.
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].score > sections[j].score })
+
+	if len(sections) == 0 {
+		c.JSON(http.StatusBadGateway, gin.H{"code": "UPSTREAM_ERROR", "message": "upstream unavailable", "errors": errs})
+	} else {
+		resp := gin.H{"keyword": keyword, "sections": sections}
+		if top != nil {
+			resp["top"] = gin.H{"type": topType, "item": top.top}
+		}
+		if len(errs) > 0 {
+			resp["errors"] = errs
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+
+	for _, o := range outcomes {
+		h.record(c, "/search/", o.rawQuery, o.entry, o.status, o.start)
+	}
+}
+
+// selectSearchTypes parses ?types=; empty selects every type.
+func selectSearchTypes(param string) ([]searchType, error) {
+	if param == "" {
+		return searchTypes, nil
+	}
+	want := make(map[string]bool)
+	for _, n := range strings.Split(param, ",") {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		known := false
+		for _, t := range searchTypes {
+			known = known || t.name == n
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown search type %q (want singers, songs, albums, mvs)", n)
+		}
+		want[n] = true
+	}
+	var out []searchType
+	for _, t := range searchTypes {
+		if want[t.name] {
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 {
+		return searchTypes, nil
+	}
+	return out, nil
+}
+
+// searchLimits returns the item limit per type name, applying valid
+// SEARCH_ALL_LIMITS overrides (1..maxSearchAllLimit) over the defaults.
+func (h *SearchHandler) searchLimits(ctx context.Context) map[string]int {
+	limits := make(map[string]int, len(searchTypes))
+	for _, t := range searchTypes {
+		limits[t.name] = t.limit
+	}
+	if v, err := h.cfgSvc.Get(ctx, cfgSearchAllLimits); err == nil && strings.TrimSpace(v) != "" {
+		var override map[string]int
+		if err := json.Unmarshal([]byte(v), &override); err == nil {
+			for name, n := range override {
+				if _, ok := limits[name]; ok && n > 0 && n <= maxSearchAllLimit {
+					limits[name] = n
+				}
+			}
+		}
+	}
+	return limits
+}
+
+// searchSectionOf translates one type's raw search body into a section of at
+// most limit items and scores its best match.
+func searchSectionOf(t searchType, keyword, rawQuery string, entry *cache.Entry, limit int) (*searchSection, error) {
+	body, err := entry.Body()
+	if err != nil {
+		return nil, err
+	}
+	v, err := catalog.Translate("/search/", rawQuery, body)
+	if err != nil {
+		return nil, err
+	}
+	switch l := v.(type) {
+	case catalog.List[catalog.Song]:
+		return newSearchSection(t.name, keyword, l, limit, func(s catalog.Song) string { return s.Name })
+	case catalog.List[catalog.Artist]:
+		return newSearchSection(t.name, keyword, l, limit, func(a catalog.Artist) string { return a.Name })
+	case catalog.List[catalog.Album]:
+		return newSearchSection(t.name, keyword, l, limit, func(a catalog.Album) string { return a.Name })
+	case catalog.List[catalog.MV]:
+		return newSearchSection(t.name, keyword, l, limit, func(m catalog.MV) string { return m.Name })
+	}
+	return nil, catalog.ErrUnsupported
+}
+
+func newSearchSection[T any](typ, keyword string, l catalog.List[T], limit int, name func(T) string) (*searchSection, error) {
+	items := l.Items[:min(limit, len(l.Items))]
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	sec := &searchSection{Type: typ, Total: l.Total, Items: data}
+	if len(items) > 0 {
+		names := make([]string, len(items))
+		for i, it := range items {
+			names[i] = name(it)
+		}
+		best := bestMatch(keyword, names)
+		sec.top, sec.score = items[best], matchScore(keyword, names[best])
+	}
+	return sec, nil
+}
+
+// bestMatch returns the index of the name matching keyword best. Upstream
+// order is its own relevance ranking, so the earliest wins a tie.
+func bestMatch(keyword string, names []string) int {
+	best, bestScore := 0, -1.0
+	for i, n := range names {
+		// A small position penalty keeps upstream order among equal matches.
+		if s := matchScore(keyword, n) - float64(i)*0.001; s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	return best
+}
+
+// matchScore rates how well name matches keyword, case-insensitively:
+// 1 exact, 0.8 prefix, 0.6 contains, otherwise the share of keyword runes
+// found in name (at most 0.5).
+func matchScore(keyword, name string) float64 {
+	k := strings.ToLower(strings.TrimSpace(keyword))
+	n := strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case k == "" || n == "":
+		return 0
+	case n == k:
+		return 1
+	case strings.HasPrefix(n, k):
+		return 0.8
+	case strings.Contains(n, k):
+		return 0.6
+	}
+	var hit, total int
+	for _, r := range k {
+		total++
+		if strings.ContainsRune(n, r) {
+			hit++
+		}
+	}
+	return 0.5 * float64(hit) / float64(total)
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"listen-stream/proxy-svc/internal/upstream"
+)
+
+// searchFixtures maps an upstream /search/ type to its captured payload in
+// the repo's api/ folder.
+var searchFixtures = map[string]string{
+	"0":  "search_song.json",
+	"9":  "search_singer.json",
+	"8":  "search_albums.json",
+	"12": "search_mv.json",
+}
+
+// searchAPI is a stub upstream answering /search/ from the fixtures, with a
+// 500 for the types in fail. It records the query of every call.
+type searchAPI struct {
+	fail map[string]bool
+
+	mu    sync.Mutex
+	calls map[string]string // type → raw query
+}
+
+func (s *searchAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	typ := r.URL.Query().Get("type")
+	s.mu.Lock()
+	s.calls[typ] = r.URL.RawQuery
+	s.mu.Unlock()
+	if r.URL.Path != "/search/" || s.fail[typ] || searchFixtures[typ] == "" {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	body, err := os.ReadFile(filepath.Join("../../../../api", searchFixtures[typ]))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(body)
+}
+
+type searchAllResponse struct {
+	Keyword string `json:"keyword"`
+	Code    string `json:"code"`
+	Top     *struct {
+		Type string          `json:"type"`
+		Item json.RawMessage `json:"item"`
+	} `json:"top"`
+	Sections []struct {
+		Type  string            `json:"type"`
+		Total int               `json:"total"`
+		Items []json.RawMessage `json:"items"`
+		Cache string            `json:"x_cache"`
+	} `json:"sections"`
+	Errors map[string]string `json:"errors"`
+}
+
+// searchAll serves one /api/search/all request against api with extra
+// config, bypassing the cache (no Redis here).
+func searchAll(t *testing.T, api *searchAPI, extra mapConfig, query string) (int, searchAllResponse) {
+	t.Helper()
+	api.calls = make(map[string]string)
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	cfg := mapConfig{"API_BASE_URL": srv.URL, "PROXY_TTLS": `{"/search/":0}`}
+	for k, v := range extra {
+		cfg[k] = v
+	}
+	log := zap.NewNop()
+	h := NewProxyHandler(upstream.New(cfg, nil, log), nil, cfg, nil, log)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewSearchHandler(h).Register(r.Group("/api/search"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/search/all?"+query, nil))
+
+	var resp searchAllResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	return w.Code, resp
+}
+
+func TestSearchAllMergesSections(t *testing.T) {
+	api := &searchAPI{}
+	code, resp := searchAll(t, api, nil, "keyword=%E5%91%A8%E6%9D%B0%E4%BC%A6")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", code, resp)
+	}
+	if len(api.calls) != len(searchTypes) {
+		t.Errorf("upstream searched types %v, want all %d", api.calls, len(searchTypes))
+	}
+	if len(resp.Sections) != len(searchTypes) || len(resp.Errors) != 0 {
+		t.Fatalf("sections %d, errors %v", len(resp.Sections), resp.Errors)
+	}
+	limits := map[string]int{}
+	for _, st := range searchTypes {
+		limits[st.name] = st.limit
+	}
+	for _, s := range resp.Sections {
+		if len(s.Items) == 0 || len(s.Items) > limits[s.Type] {
+			t.Errorf("%s: %d items, want 1..%d", s.Type, len(s.Items), limits[s.Type])
+		}
+		if s.Total < len(s.Items) {
+			t.Errorf("%s: total %d below item count %d", s.Type, s.Total, len(s.Items))
+		}
+		if s.Cache != "MISS" {
+			t.Errorf("%s: x_cache = %q, want MISS", s.Type, s.Cache)
+		}
+	}
+	// The singer named exactly like the keyword outranks partial matches.
+	if resp.Sections[0].Type != "singers" {
+		t.Errorf("first section = %q, want singers", resp.Sections[0].Type)
+	}
+	if resp.Top == nil || resp.Top.Type != "singer" {
+		t.Fatalf("top = %+v, want the singer", resp.Top)
+	}
+	var top struct{ Name string }
+	if err := json.Unmarshal(resp.Top.Item, &top); err != nil || top.Name != "周杰伦" {
+		t.Errorf("top item = %s (%v)", resp.Top.Item, err)
+	}
+}
+
+func TestSearchAllLimitsAndTypes(t *testing.T) {
+	api := &searchAPI{}
+	code, resp := searchAll(t, api, mapConfig{cfgSearchAllLimits: `{"songs":2,"mvs":99}`},
+		"q=%E6%99%B4%E5%A4%A9&types=songs,mvs")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", code, resp)
+	}
+	if _, ok := api.calls["9"]; ok || len(api.calls) != 2 {
+		t.Errorf("upstream searched %v, want songs and mvs only", api.calls)
+	}
+	if q := api.calls["0"]; q != "keyword=%E6%99%B4%E5%A4%A9&page=1&size=2&type=0" {
+		t.Errorf("songs query = %q", q)
+	}
+	// An out-of-range override keeps the default.
+	if q := api.calls["12"]; q != "keyword=%E6%99%B4%E5%A4%A9&page=1&size=6&type=12" {
+		t.Errorf("mvs query = %q", q)
+	}
+	got := map[string]int{}
+	for _, s := range resp.Sections {
+		got[s.Type] = len(s.Items)
+	}
+	if got["songs"] != 2 || len(got) != 2 {
+		t.Errorf("section sizes = %v", got)
+	}
+	// Both sections hold an exact "晴天": the tie keeps searchTypes order.
+	if len(resp.Sections) != 2 || resp.Sections[0].Type != "songs" || resp.Sections[1].Type != "mvs" {
+		t.Errorf("section order = %v, want songs then mvs", got)
+	}
+	if resp.Top == nil || resp.Top.Type != "song" {
+		t.Errorf("top = %+v, want the song", resp.Top)
+	}
+}
+
+func TestSearchAllPartialFailure(t *testing.T) {
+	api := &searchAPI{fail: map[string]bool{"12": true, "8": true}}
+	code, resp := searchAll(t, api, nil, "keyword=x")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, resp %+v", code, resp)
+	}
+	want := map[string]string{"mvs": "upstream unavailable", "albums": "upstream unavailable"}
+	if len(resp.Errors) != len(want) || resp.Errors["mvs"] != want["mvs"] || resp.Errors["albums"] != want["albums"] {
+		t.Errorf("errors = %v, want %v", resp.Errors, want)
+	}
+	var types []string
+	for _, s := range resp.Sections {
+		types = append(types, s.Type)
+	}
+	sort.Strings(types)
+	if len(types) != 2 || types[0] != "singers" || types[1] != "songs" {
+		t.Errorf("sections = %v, want singers and songs", types)
+	}
+}
+
+func TestSearchAllEveryTypeFails(t *testing.T) {
+	api := &searchAPI{fail: map[string]bool{"0": true, "9": true, "8": true, "12": true}}
+	code, resp := searchAll(t, api, nil, "keyword=x")
+	if code != http.StatusBadGateway || resp.Code != "UPSTREAM_ERROR" {
+		t.Fatalf("status = %d, code %q; want 502 UPSTREAM_ERROR", code, resp.Code)
+	}
+	if len(resp.Errors) != len(searchTypes) {
+		t.Errorf("errors = %v, want one per type", resp.Errors)
+	}
+}
+
+func TestSearchAllBadRequest(t *testing.T) {
+	for _, query := range []string{"", "types=songs", "keyword=x&types=songs,lyrics"} {
+		api := &searchAPI{}
+		if code, _ := searchAll(t, api, nil, query); code != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, want 400", query, code)
+		}
+		if len(api.calls) != 0 {
+			t.Errorf("%q: upstream called %v", query, api.calls)
+		}
+	}
+}
+
+func TestSearchMatchScore(t *testing.T) {
+	cases := []struct {
+		keyword, name string
+		want          float64
+	}{
+		{"周杰伦", "周杰伦", 1},
+		{"jay", "JAY", 1},
+		{"晴天", "晴天 (Live)", 0.8},
+		{"晴天", "我的晴天", 0.6},
+		{"周杰伦", "周杰", 0.5 * 2 / 3},
+		{"abc", "xyz", 0},
+		{"", "x", 0},
+	}
+	for _, tc := range cases {
+		if got := matchScore(tc.keyword, tc.name); got != tc.want {
+			t.Errorf("matchScore(%q, %q) = %.3f, want %.3f", tc.keyword, tc.name, got, tc.want)
+		}
+	}
+	// Equal matches keep upstream order.
+	if got := bestMatch("晴天", []string{"晴天 (Live)", "晴天", "晴天"}); got != 1 {
+		t.Errorf("bestMatch = %d, want 1", got)
+	}
+}
@@ -3,8 +3,6 @@ package handler
 import (
 	"net/http"
 
-	pxcfg "listen-stream/proxy-svc/internal/config"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,11 +17,12 @@ func (h *SearchHandler) Register(rg *gin.RouterGroup) {
 	rg.GET("/singers", h.searchSingers)
 	rg.GET("/albums",  h.searchAlbums)
 	rg.GET("/mvs",     h.searchMvs)
+	rg.GET("/all",     h.searchAll) // GET /api/search/all?keyword=...&types=...
 	rg.GET("",         h.search)  // GET /api/search?keyword=...
 }
 
 func (h *SearchHandler) hotkey(c *gin.Context) {
-	h.handle(c, "/search/hotkey", pxcfg.ProxyTTL["/search/hotkey"])
+	h.handle(c, "/search/hotkey")
 }
 
 func (h *SearchHandler) searchSongs(c *gin.Context) {
@@ -33,7 +32,7 @@ func (h *SearchHandler) searchSongs(c *gin.Context) {
 	}
 	// Upstream API: /search/?keyword=xxx&type=0
 	c.Request.URL.RawQuery += "&type=0"
-	h.handle(c, "/search/", pxcfg.ProxyTTL["/search/"])
+	h.handle(c, "/search/")
 }
 
 func (h *SearchHandler) searchSingers(c *gin.Context) {
@@ -43,7 +42,7 @@ func (h *SearchHandler) searchSingers(c *gin.Context) {
 	}
 	// Upstream API: /search/?keyword=xxx&type=9
 	c.Request.URL.RawQuery += "&type=9"
-	h.handle(c, "/search/", pxcfg.ProxyTTL["/search/"])
+	h.handle(c, "/search/")
 }
 
 func (h *SearchHandler) searchAlbums(c *gin.Context) {
@@ -53,7 +52,7 @@ func (h *SearchHandler) searchAlbums(c *gin.Context) {
 	}
 	// Upstream API: /search/?keyword=xxx&type=8
 	c.Request.URL.RawQuery += "&type=8"
-	h.handle(c, "/search/", pxcfg.ProxyTTL["/search/"])
+	h.handle(c, "/search/")
 }
 
 func (h *SearchHandler) searchMvs(c *gin.Context) {
@@ -63,7 +62,7 @@ func (h *SearchHandler) searchMvs(c *gin.Context) {
 	}
 	// Upstream API: /search/?keyword=xxx&type=12
 	c.Request.URL.RawQuery += "&type=12"
-	h.handle(c, "/search/", pxcfg.ProxyTTL["/search/"])
+	h.handle(c, "/search/")
 }
 
 // search validates the required keyword param.
@@ -72,5 +71,5 @@ func (h *SearchHandler) search(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_PARAM", "message": "keyword is required"})
 		return
 	}
-	h.handle(c, "/search/", pxcfg.ProxyTTL["/search/"])
+	h.handle(c, "/search/")
 }
@@ -3,8 +3,6 @@ package handler
 import (
 	"net/http"
 
-	pxcfg "listen-stream/proxy-svc/internal/config"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,7 +21,7 @@ func (h *SingerHandler) Register(rg *gin.RouterGroup) {
 }
 
 func (h *SingerHandler) category(c *gin.Context) {
-	h.handle(c, "/artist/category", pxcfg.ProxyTTL["/artist/category"])
+	h.handle(c, "/artist/category")
 }
 
 // list validates the required area/sex/genre/index query params.
@@ -32,21 +30,21 @@ func (h *SingerHandler) list(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_PARAM", "message": "area is required"})
 		return
 	}
-	h.handle(c, "/artist/list", pxcfg.ProxyTTL["/artist/list"])
+	h.handle(c, "/artist/list")
 }
 func (h *SingerHandler) detail(c *gin.Context) {
 	// Client sends 'mid', upstream expects 'id'
-	h.handleWithParamMap(c, "/artist/detail", pxcfg.ProxyTTL["/artist/detail"], map[string]string{"mid": "id"})
+	h.handleWithParamMap(c, "/artist/detail", map[string]string{"mid": "id"})
 }
 func (h *SingerHandler) albums(c *gin.Context) {
 	// Client sends 'mid', upstream expects 'id'
-	h.handleWithParamMap(c, "/artist/albums", pxcfg.ProxyTTL["/artist/albums"], map[string]string{"mid": "id"})
+	h.handleWithParamMap(c, "/artist/albums", map[string]string{"mid": "id"})
 }
 func (h *SingerHandler) mvs(c *gin.Context) {
 	// Client sends 'mid', upstream expects 'id'
-	h.handleWithParamMap(c, "/artist/mvs", pxcfg.ProxyTTL["/artist/mvs"], map[string]string{"mid": "id"})
+	h.handleWithParamMap(c, "/artist/mvs", map[string]string{"mid": "id"})
 }
 func (h *SingerHandler) songs(c *gin.Context) {
 	// Client sends 'mid', upstream expects 'id'
-	h.handleWithParamMap(c, "/artist/songs", pxcfg.ProxyTTL["/artist/songs"], map[string]string{"mid": "id"})
+	h.handleWithParamMap(c, "/artist/songs", map[string]string{"mid": "id"})
 }
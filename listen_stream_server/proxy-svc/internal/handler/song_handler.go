@@ -1,28 +1,35 @@
 package handler
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
-	"net/url"
+	"strconv"
 
-	pxcfg "listen-stream/proxy-svc/internal/config"
+	"listen-stream/proxy-svc/internal/playback"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // SongHandler serves /api/song endpoints.
-type SongHandler struct{ *ProxyHandler }
+type SongHandler struct {
+	*ProxyHandler
+	sources *playback.Registry
+	relay   *playback.Relay
+}
 
 // NewSongHandler creates a SongHandler.
-func NewSongHandler(base *ProxyHandler) *SongHandler { return &SongHandler{base} }
+// sources is the playback-source chain used by /api/song/url and /api/song/stream.
+func NewSongHandler(base *ProxyHandler, sources *playback.Registry) *SongHandler {
+	return &SongHandler{ProxyHandler: base, sources: sources, relay: playback.NewRelay(sources, base.log)}
+}
 
-// Register mounts routes under /api/song
-func (h *SongHandler) Register(rg *gin.RouterGroup) {
+// Register mounts routes under /api/song. The audio relay goes on stream, a
+// group for the same prefix with its own rate limit: players send a Range
+// request per seek, far more than the JSON endpoints see.
+func (h *SongHandler) Register(rg, stream *gin.RouterGroup) {
     rg.GET("/detail", h.detail) // GET /api/song/detail?id=...
-    rg.GET("/url", h.url)        // GET /api/song/url?id=...&name=...
+    rg.GET("/url", h.url)        // GET /api/song/url?id=...&name=...&quality=...
+    stream.GET("/stream", h.stream) // GET /api/song/stream?id=...&name=...&quality=...
 }
 
 // detail requires id param and forwards to upstream /song/detail.
@@ -31,136 +38,100 @@ func (h *SongHandler) detail(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_PARAM", "message": "id is required"})
         return
     }
-    h.handle(c, "/song/detail", pxcfg.ProxyTTL["/song/detail"])
+    h.handle(c, "/song/detail")
 }
 
-// url fetches song playback URL with QQ → Joox fallback.
-// Query params: id (required, song mid), name (optional, used for Joox search).
+// url fetches the song playback URL by walking the playback-source chain
+// (PLAYBACK_SOURCES, default QQ → Joox).
+// Query params: id (required, song mid), name (optional, used for Joox search),
+// artist, album, duration (optional seconds; Joox match hints),
+// quality (optional: standard (default), high, lossless).
+//
+// The requested quality is capped at the caller's role entitlement
+// (PLAYBACK_QUALITY_POLICY); tiers are then tried from there downwards and
+// the one actually obtained is returned as "quality".
 // Returns unified response:
-//   Success: {"code": 1, "message": "Success", "url": "...", "source": "qq|joox", "songmid": "..."}
-//   Failure: {"code": 0, "message": "暂无播放权限", "url": null}
+//
+//	Success: {"code": 1, "message": "Success", "url": "...", "source": "qq|joox", "quality": "high", "songmid": "..."}
+//	Failure: {"code": 0, "message": "暂无播放权限", "url": null}
 func (h *SongHandler) url(c *gin.Context) {
-    id := c.Query("id")
-    name := c.Query("name")
-    if id == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_PARAM", "message": "id is required"})
-        return
-    }
-
-    ctx := c.Request.Context()
-
-    // ── 1. Try primary source (QQ Music) ─────────────────────────────────────
-    qqURL, err := h.tryQQMusic(ctx, id)
-    if err == nil && qqURL != "" {
-        c.JSON(http.StatusOK, gin.H{
-            "code":    1,
-            "message": "Success",
-            "url":     qqURL,
-            "source":  "qq",
-            "songmid": id,
-        })
-        return
-    }
-
-    h.log.Info("QQ Music failed, trying Joox fallback", zap.String("id", id), zap.Error(err))
-
-    // ── 2. Fallback to Joox ──────────────────────────────────────────────────
-    if name == "" {
-        // If no name provided, cannot search Joox
-        c.JSON(http.StatusOK, gin.H{
-            "code":    0,
-            "message": "暂无播放权限",
-            "url":     nil,
-        })
-        return
-    }
-
-    jooxURL, err := h.tryJooxFallback(ctx, name)
-    if err == nil && jooxURL != "" {
-        c.JSON(http.StatusOK, gin.H{
-            "code":    1,
-            "message": "Success",
-            "url":     jooxURL,
-            "source":  "joox",
-            "songmid": id,
-        })
-        return
-    }
-
-    h.log.Info("Joox fallback failed", zap.String("id", id), zap.String("name", name), zap.Error(err))
-
-    // ── 3. No playback available ─────────────────────────────────────────────
-    c.JSON(http.StatusOK, gin.H{
-        "code":    0,
-        "message": "暂无播放权限",
-        "url":     nil,
-    })
+	req, ok := h.playbackRequest(c)
+	if !ok {
+		return
+	}
+	id := req.SongMID
+
+	res := h.sources.Resolve(c.Request.Context(), req)
+	for _, a := range res.Attempts {
+		if a.Reason == "" {
+			continue
+		}
+		h.log.Info("playback source failed",
+			zap.String("id", id),
+			zap.String("source", a.Source),
+			zap.String("quality", string(a.Quality)),
+			zap.Duration("latency", a.Latency),
+			zap.String("reason", a.Reason))
+	}
+
+	if res.URL == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    0,
+			"message": "暂无播放权限",
+			"url":     nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    1,
+		"message": "Success",
+		"url":     res.URL,
+		"source":  res.Source,
+		"quality": res.Quality,
+		"songmid": id,
+	})
 }
 
-// tryQQMusic requests /song/url?id={id} from primary upstream.
-// Returns URL if code=1, empty string otherwise.
-func (h *SongHandler) tryQQMusic(ctx context.Context, id string) (string, error) {
-    body, err := h.client.Do(ctx, "/song/url", fmt.Sprintf("id=%s", id))
-    if err != nil {
-        return "", fmt.Errorf("qq music request failed: %w", err)
-    }
-
-    var resp struct {
-        Code int    `json:"code"`
-        Data struct {
-            URL string `json:"url"`
-        } `json:"data"`
-    }
-    if err := json.Unmarshal(body, &resp); err != nil {
-        return "", fmt.Errorf("qq music parse failed: %w", err)
-    }
-
-    if resp.Code == 1 && resp.Data.URL != "" {
-        return resp.Data.URL, nil
-    }
-    return "", fmt.Errorf("qq music returned code=%d", resp.Code)
+// stream relays the song's audio through proxy-svc, resolving the source URL
+// with the same chain as url. The source URL is never exposed to the client.
+// Query params: as for url.
+// Range requests are forwarded and answered with 206 when the audio host supports them.
+func (h *SongHandler) stream(c *gin.Context) {
+	req, ok := h.playbackRequest(c)
+	if !ok {
+		return
+	}
+	h.relay.Serve(c.Writer, c.Request, req)
 }
 
-// tryJooxFallback uses fallback API to search and get playback URL from Joox.
-// Step a: Search by song name → get Joox id
-// Step b: Get URL by Joox id
-func (h *SongHandler) tryJooxFallback(ctx context.Context, songName string) (string, error) {
-    // Step a: Search
-    searchQuery := fmt.Sprintf("types=search&source=joox&name=%s", url.QueryEscape(songName))
-    searchBody, err := h.client.DoFallback(ctx, "", searchQuery)
-    if err != nil {
-        return "", fmt.Errorf("joox search failed: %w", err)
-    }
-
-    var searchResp []struct {
-        ID   string `json:"id"`
-        Name string `json:"name"`
-    }
-    if err := json.Unmarshal(searchBody, &searchResp); err != nil {
-        return "", fmt.Errorf("joox search parse failed: %w", err)
-    }
-    if len(searchResp) == 0 {
-        return "", fmt.Errorf("joox search returned no results")
-    }
-
-    jooxID := searchResp[0].ID
-
-    // Step b: Get URL
-    urlQuery := fmt.Sprintf("types=url&source=joox&id=%s", url.QueryEscape(jooxID))
-    urlBody, err := h.client.DoFallback(ctx, "", urlQuery)
-    if err != nil {
-        return "", fmt.Errorf("joox url request failed: %w", err)
-    }
-
-    var urlResp struct {
-        URL string `json:"url"`
-    }
-    if err := json.Unmarshal(urlBody, &urlResp); err != nil {
-        return "", fmt.Errorf("joox url parse failed: %w", err)
-    }
-
-    if urlResp.URL == "" {
-        return "", fmt.Errorf("joox returned empty url")
-    }
-    return urlResp.URL, nil
+// playbackRequest builds the playback.Request shared by url and stream,
+// capping the quality param at the caller's entitlement. On invalid params
+// it writes a 400 and returns false.
+func (h *SongHandler) playbackRequest(c *gin.Context) (playback.Request, bool) {
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_PARAM", "message": "id is required"})
+		return playback.Request{}, false
+	}
+	want, err := playback.ParseQuality(c.Query("quality"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": err.Error()})
+		return playback.Request{}, false
+	}
+	duration := 0
+	if v := c.Query("duration"); v != "" {
+		if duration, err = strconv.Atoi(v); err != nil || duration < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": "duration must be a whole number of seconds"})
+			return playback.Request{}, false
+		}
+	}
+	return playback.Request{
+		SongMID:     id,
+		Name:        c.Query("name"),
+		Artist:      c.Query("artist"),
+		Album:       c.Query("album"),
+		DurationSec: duration,
+		Quality:     h.sources.Grant(c.Request.Context(), c.GetString("role"), want),
+	}, true
 }
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"listen-stream/proxy-svc/internal/upstream"
+	"listen-stream/shared/pkg/config"
+	"listen-stream/shared/pkg/tracing"
+)
+
+var spans = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	shutdown, err := tracing.Setup(context.Background(), "proxy-svc", tracing.WithExporter(spans))
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	_ = shutdown(context.Background())
+	os.Exit(code)
+}
+
+// mapConfig is a config.Service serving fixed values.
+type mapConfig map[string]string
+
+func (m mapConfig) Get(_ context.Context, key string) (string, error) {
+	if v, ok := m[key]; ok {
+		return v, nil
+	}
+	return "", config.ErrConfigNotFound
+}
+
+func (m mapConfig) GetMany(_ context.Context, keys []string) (map[string]string, error) {
+	out := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+func (mapConfig) Set(context.Context, string, string, string) error { return nil }
+func (mapConfig) Preload(context.Context) error                     { return nil }
+func (mapConfig) Invalidate(string)                                 {}
+
+// TestTraceGinToUpstream follows one request through the Gin middleware,
+// ProxyHandler and upstream.Client: the upstream call must be part of the
+// request's trace, and its traceparent must not reach the third-party API.
+func TestTraceGinToUpstream(t *testing.T) {
+	spans.Reset()
+	var traceparent []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = append(traceparent, r.Header.Get("traceparent"))
+		_, _ = w.Write([]byte(`{"code": 1, "message": "Success", "data": []}`))
+	}))
+	defer api.Close()
+
+	log := zap.NewNop()
+	cfg := mapConfig{"API_BASE_URL": api.URL}
+	// No Redis: /radio/songlist is uncached (TTL 0), so only upstream is hit.
+	h := NewProxyHandler(upstream.New(cfg, nil, log), nil, cfg, nil, log)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(tracing.Middleware("proxy-svc"))
+	NewRadioHandler(h).Register(r.Group("/api/radio"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/radio/songlist?id=99", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	var server, call, client *tracetest.SpanStub
+	got := spans.GetSpans()
+	for i := range got {
+		s := &got[i]
+		switch {
+		case s.SpanKind == trace.SpanKindServer:
+			server = s
+		case s.SpanKind == trace.SpanKindClient:
+			client = s
+		case s.Name == "upstream /radio/songlist":
+			call = s
+		}
+	}
+	if server == nil || call == nil || client == nil {
+		t.Fatalf("missing spans; got %v", spanNames(got))
+	}
+	if call.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("%q is not a child of the server span %q", call.Name, server.Name)
+	}
+	if client.Parent.SpanID() != call.SpanContext.SpanID() {
+		t.Errorf("%q is not a child of %q", client.Name, call.Name)
+	}
+	for _, s := range []*tracetest.SpanStub{call, client} {
+		if s.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("%q is in trace %s, want %s", s.Name, s.SpanContext.TraceID(), server.SpanContext.TraceID())
+		}
+	}
+	if len(traceparent) != 1 || traceparent[0] != "" {
+		t.Errorf("upstream API received traceparent %q", traceparent)
+	}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	out := make([]string, len(spans))
+	for i, s := range spans {
+		out[i] = s.Name
+	}
+	return out
+}
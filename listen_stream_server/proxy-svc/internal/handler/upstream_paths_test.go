@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"listen-stream/shared/pkg/proxyttl"
+)
+
+// proxiedCalls are the ProxyHandler methods whose second argument is the
+// upstream path, which is also its proxyttl key.
+var proxiedCalls = map[string]bool{
+	"handle": true, "handleWithParamMap": true, "handleWithQuery": true,
+	"load": true, "record": true,
+}
+
+// TestUpstreamPathsHaveTTLs fails when a handler proxies an upstream path
+// that proxyttl.Defaults does not list. At run time such a path is logged
+// and silently left uncached; this catches it before it ships.
+func TestUpstreamPathsHaveTTLs(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	seen := 0
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !proxiedCalls[sel.Sel.Name] {
+				return true
+			}
+			lit, ok := call.Args[1].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			p, _ := strconv.Unquote(lit.Value)
+			seen++
+			if _, ok := proxyttl.Defaults[p]; !ok {
+				t.Errorf("%s: upstream path %q has no entry in proxyttl.Defaults", fset.Position(lit.Pos()), p)
+			}
+			return true
+		})
+	}
+	if seen == 0 {
+		t.Fatal("found no proxied upstream paths; has the handler API changed?")
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Warm refreshes the cache entry for (upstreamPath, rawQuery) when it is
+// missing or will stop being served as a fresh HIT within lead.
+// It goes through fetch, so a warm pass is coalesced with concurrent client
+// misses on the same key. refreshed reports whether upstream was called.
+//
+// rawQuery must be in the form clients send (it is part of the cache key).
+func (h *ProxyHandler) Warm(ctx context.Context, upstreamPath, rawQuery string, lead time.Duration) (refreshed bool, err error) {
+	ttl, ok := h.ttls.Get(ctx, upstreamPath)
+	if !ok || ttl <= 0 {
+		return false, fmt.Errorf("warm: %s is not a cached path", upstreamPath)
+	}
+	cacheKey := buildCacheKey(upstreamPath, rawQuery)
+
+	// A hit stops being fresh at the soft TTL (when SWR is on), else at ttl.
+	fresh := h.softTTL(ctx, ttl)
+	if fresh == 0 {
+		fresh = ttl
+	}
+	if e, gerr := h.cache.Get(ctx, cacheKey); gerr == nil && e != nil && fresh-e.Age() > lead {
+		return false, nil
+	}
+
+	if _, err := h.fetch(ctx, upstreamPath, rawQuery, cacheKey, ttl); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,117 @@
+// Package lyric parses LRC lyrics, merges an original track with its
+// translation, and renders the result as JSON-ready lines, LRC, SRT or WebVTT.
+package lyric
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Line is one timed lyric line. Several Lines may share a Time when the source
+// repeats a chorus with multiple timestamps ("[00:12.00][01:40.00]…").
+type Line struct {
+	Time time.Duration
+	Text string
+}
+
+// Document is a parsed LRC file.
+type Document struct {
+	// Meta holds ID tags such as ti, ar, al, by (keys lower-cased).
+	Meta map[string]string
+	// Offset is the [offset:±ms] tag; it has already been applied to Lines.
+	Offset time.Duration
+	// Lines are sorted by Time (stable, so same-time lines keep file order).
+	Lines []Line
+}
+
+// Parse parses LRC text. It never fails: unrecognised lines are skipped, so a
+// plain-text (untimed) lyric yields a Document with no Lines.
+//
+// Supported:
+//   - multiple leading timestamps per line: [mm:ss.xx][mm:ss.xx]text
+//   - timestamps as mm:ss, mm:ss.x, mm:ss.xx, mm:ss.xxx and mm:ss:xx
+//   - ID tags ([ti:…], [ar:…], …) and [offset:±ms], where a positive offset
+//     makes lyrics appear earlier, per the LRC convention
+func Parse(text string) *Document {
+	doc := &Document{Meta: make(map[string]string)}
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(strings.TrimSuffix(raw, "\r"))
+		var times []time.Duration
+		for strings.HasPrefix(line, "[") {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				break
+			}
+			tag := line[1:end]
+			if t, ok := parseTimestamp(tag); ok {
+				times = append(times, t)
+			} else if len(times) == 0 {
+				if k, v, ok := strings.Cut(tag, ":"); ok {
+					k = strings.ToLower(strings.TrimSpace(k))
+					v = strings.TrimSpace(v)
+					doc.Meta[k] = v
+					if k == "offset" {
+						if ms, err := strconv.Atoi(v); err == nil {
+							doc.Offset = time.Duration(ms) * time.Millisecond
+						}
+					}
+				}
+			} else {
+				break // "[" inside the lyric text itself
+			}
+			line = line[end+1:]
+		}
+		text := strings.TrimSpace(line)
+		for _, t := range times {
+			doc.Lines = append(doc.Lines, Line{Time: t, Text: text})
+		}
+	}
+
+	if doc.Offset != 0 {
+		for i := range doc.Lines {
+			doc.Lines[i].Time -= doc.Offset
+			if doc.Lines[i].Time < 0 {
+				doc.Lines[i].Time = 0
+			}
+		}
+	}
+	sort.SliceStable(doc.Lines, func(i, j int) bool { return doc.Lines[i].Time < doc.Lines[j].Time })
+	return doc
+}
+
+// parseTimestamp parses "mm:ss", "mm:ss.f{1,3}" or "mm:ss:ff".
+func parseTimestamp(s string) (time.Duration, bool) {
+	mm, rest, ok := strings.Cut(s, ":")
+	if !ok || mm == "" {
+		return 0, false
+	}
+	ss, frac, hasFrac := strings.Cut(rest, ".")
+	if !hasFrac {
+		ss, frac, hasFrac = strings.Cut(rest, ":")
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 0 {
+		return 0, false
+	}
+	sec, err := strconv.Atoi(ss)
+	if err != nil || sec < 0 || sec >= 60 || len(ss) == 0 {
+		return 0, false
+	}
+	d := time.Duration(m)*time.Minute + time.Duration(sec)*time.Second
+	if hasFrac {
+		if len(frac) == 0 || len(frac) > 3 {
+			return 0, false
+		}
+		f, err := strconv.Atoi(frac)
+		if err != nil || f < 0 {
+			return 0, false
+		}
+		for i := len(frac); i < 3; i++ {
+			f *= 10
+		}
+		d += time.Duration(f) * time.Millisecond
+	}
+	return d, true
+}
@@ -0,0 +1,98 @@
+package lyric
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func ms(n int64) time.Duration { return time.Duration(n) * time.Millisecond }
+
+func TestParseMultipleTimestamps(t *testing.T) {
+	doc := Parse("[ti:晴天]\r\n[ar:周杰伦]\n[00:01.5]intro\n[00:12.00][01:40.00]chorus\n[00:20.123]verse [live]\nplain text\n[01:02:50]colon frac\n")
+	if doc.Meta["ti"] != "晴天" || doc.Meta["ar"] != "周杰伦" {
+		t.Errorf("meta = %v", doc.Meta)
+	}
+	want := []Line{
+		{ms(1500), "intro"},
+		{ms(12000), "chorus"},
+		{ms(20123), "verse [live]"},
+		{ms(62500), "colon frac"},
+		{ms(100000), "chorus"},
+	}
+	if len(doc.Lines) != len(want) {
+		t.Fatalf("lines = %+v, want %+v", doc.Lines, want)
+	}
+	for i, l := range doc.Lines {
+		if l != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, l, want[i])
+		}
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	doc := Parse("[offset:500]\n[00:00.20]a\n[00:02.00]b\n")
+	if doc.Offset != ms(500) {
+		t.Fatalf("offset = %v", doc.Offset)
+	}
+	if doc.Lines[0].Time != 0 || doc.Lines[1].Time != ms(1500) {
+		t.Errorf("lines = %+v, want offset applied and clamped at 0", doc.Lines)
+	}
+}
+
+func TestMergeTranslation(t *testing.T) {
+	orig := Parse("[00:01.00]one\n[00:03.00]two\n[00:05.00]\n[00:07.00]three\n[00:09.00]four\n")
+	trans := Parse("[00:01.00]一\n[00:03.20]二\n[00:07.00]//\n[00:09.90]四\n")
+	cues := Merge(orig, trans)
+	want := []Cue{
+		{ms(1000), ms(3000), "one", "一"},
+		{ms(3000), ms(5000), "two", "二"},  // 200 ms drift is within tolerance
+		{ms(7000), ms(9000), "three", ""}, // "//" means untranslated
+		{ms(9000), ms(14000), "four", ""}, // 900 ms away: not paired; last line held 5 s
+	}
+	if len(cues) != len(want) {
+		t.Fatalf("cues = %+v, want %+v", cues, want)
+	}
+	for i, c := range cues {
+		if c != want[i] {
+			t.Errorf("cue %d = %+v, want %+v", i, c, want[i])
+		}
+	}
+}
+
+func TestMergeRepeatedChorus(t *testing.T) {
+	orig := Parse("[00:10.00][00:30.00]la la\n[00:20.00]verse\n")
+	trans := Parse("[00:10.00][00:30.00]啦啦\n")
+	cues := Merge(orig, trans)
+	if len(cues) != 3 || cues[0].Translation != "啦啦" || cues[2].Translation != "啦啦" || cues[1].Translation != "" {
+		t.Errorf("cues = %+v", cues)
+	}
+}
+
+func TestRenderEscapesCueText(t *testing.T) {
+	cues := []Cue{{Start: ms(1000), End: ms(2500), Text: "A --> B <i>&", Translation: "line\n\nbreak"}}
+
+	vtt := Render(FormatVTT, nil, cues)
+	wantVTT := "WEBVTT\n\n00:00:01.000 --> 00:00:02.500\nA --&gt; B &lt;i&gt;&amp;\nline break\n\n"
+	if vtt != wantVTT {
+		t.Errorf("vtt =\n%q\nwant\n%q", vtt, wantVTT)
+	}
+
+	srt := Render(FormatSRT, nil, cues)
+	wantSRT := "1\n00:00:01,000 --> 00:00:02,500\nA -> B ‹i>&\nline break\n\n"
+	if srt != wantSRT {
+		t.Errorf("srt =\n%q\nwant\n%q", srt, wantSRT)
+	}
+	if strings.Count(srt, "-->") != 1 || strings.Count(vtt, "-->") != 1 {
+		t.Error("cue text still contains a timing arrow")
+	}
+}
+
+func TestRenderLRC(t *testing.T) {
+	cues := []Cue{{Start: ms(61230), End: ms(65000), Text: "hello", Translation: "你好"}}
+	got := Render(FormatLRC, map[string]string{"ti": "T", "ar": "A"}, cues)
+	want := "[ti:T]\n[ar:A]\n[01:01.23]hello\n[01:01.23]你好\n"
+	if got != want {
+		t.Errorf("lrc = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package lyric
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// mergeTolerance is how far a translated line's timestamp may drift from
+	// the original's and still be paired with it. Upstream translations are
+	// usually timed identically, but some are rounded differently.
+	mergeTolerance = 300 * time.Millisecond
+	// lastLineHold is how long the final line stays on screen.
+	lastLineHold = 5 * time.Second
+)
+
+// Cue is a merged line with an explicit display window.
+type Cue struct {
+	Start       time.Duration
+	End         time.Duration
+	Text        string
+	Translation string
+}
+
+// Merge pairs each original line with the translated line closest in time
+// (within mergeTolerance) and computes display windows: a cue ends where the
+// next cue with a later timestamp starts. trans may be nil.
+//
+// Blank original lines are kept as gaps (they end the previous cue) but are
+// not emitted. Upstream uses "//" as the translation of untranslatable lines;
+// it is treated as no translation.
+func Merge(orig, trans *Document) []Cue {
+	var tl []Line
+	if trans != nil {
+		tl = trans.Lines
+	}
+	used := make([]bool, len(tl))
+
+	cues := make([]Cue, 0, len(orig.Lines))
+	for i, l := range orig.Lines {
+		if l.Text == "" {
+			continue
+		}
+		end := l.Time + lastLineHold
+		for _, next := range orig.Lines[i+1:] {
+			if next.Time > l.Time {
+				end = next.Time
+				break
+			}
+		}
+		cue := Cue{Start: l.Time, End: end, Text: l.Text}
+		if j := nearest(tl, used, l.Time); j >= 0 {
+			used[j] = true
+			if t := tl[j].Text; t != "//" {
+				cue.Translation = strings.TrimSpace(t)
+			}
+		}
+		cues = append(cues, cue)
+	}
+	return cues
+}
+
+// nearest returns the index of the unused line in ls (sorted by Time) closest
+// to t within mergeTolerance, or -1.
+func nearest(ls []Line, used []bool, t time.Duration) int {
+	i := sort.Search(len(ls), func(i int) bool { return ls[i].Time >= t-mergeTolerance })
+	best, bestDiff := -1, mergeTolerance+1
+	for ; i < len(ls) && ls[i].Time <= t+mergeTolerance; i++ {
+		if used[i] || ls[i].Text == "" {
+			continue
+		}
+		d := ls[i].Time - t
+		if d < 0 {
+			d = -d
+		}
+		if d < bestDiff {
+			best, bestDiff = i, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,130 @@
+package lyric
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Output formats accepted by Render.
+const (
+	FormatJSON = "json"
+	FormatLRC  = "lrc"
+	FormatSRT  = "srt"
+	FormatVTT  = "vtt"
+)
+
+// ContentType returns the response Content-Type for a text format.
+func ContentType(format string) string {
+	switch format {
+	case FormatSRT:
+		return "application/x-subrip; charset=utf-8"
+	case FormatVTT:
+		return "text/vtt; charset=utf-8"
+	case FormatLRC:
+		return "text/plain; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// JSONCue is the wire form of a Cue in format=json responses.
+type JSONCue struct {
+	StartMS     int64  `json:"start_ms"`
+	EndMS       int64  `json:"end_ms"`
+	Text        string `json:"text"`
+	Translation string `json:"translation,omitempty"`
+}
+
+// JSON is the format=json payload.
+type JSON struct {
+	Title          string    `json:"title,omitempty"`
+	Artist         string    `json:"artist,omitempty"`
+	Album          string    `json:"album,omitempty"`
+	HasTranslation bool      `json:"has_translation"`
+	Lines          []JSONCue `json:"lines"`
+}
+
+// ToJSON builds the format=json payload from meta tags and merged cues.
+func ToJSON(meta map[string]string, cues []Cue) JSON {
+	out := JSON{Title: meta["ti"], Artist: meta["ar"], Album: meta["al"], Lines: make([]JSONCue, 0, len(cues))}
+	for _, c := range cues {
+		out.Lines = append(out.Lines, JSONCue{
+			StartMS:     c.Start.Milliseconds(),
+			EndMS:       c.End.Milliseconds(),
+			Text:        c.Text,
+			Translation: c.Translation,
+		})
+		if c.Translation != "" {
+			out.HasTranslation = true
+		}
+	}
+	return out
+}
+
+// Render writes cues as LRC, SRT or WebVTT. LRC puts the translation on its
+// own line with the same timestamp; SRT and WebVTT put it under the original.
+// Cue text is escaped for the format (see srtEscaper, vttEscaper).
+func Render(format string, meta map[string]string, cues []Cue) string {
+	var b strings.Builder
+	switch format {
+	case FormatLRC:
+		for _, k := range []string{"ti", "ar", "al"} {
+			if v := meta[k]; v != "" {
+				fmt.Fprintf(&b, "[%s:%s]\n", k, v)
+			}
+		}
+		for _, c := range cues {
+			ts := lrcTime(c.Start)
+			fmt.Fprintf(&b, "[%s]%s\n", ts, c.Text)
+			if c.Translation != "" {
+				fmt.Fprintf(&b, "[%s]%s\n", ts, c.Translation)
+			}
+		}
+	case FormatSRT:
+		for i, c := range cues {
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, clock(c.Start, ','), clock(c.End, ','), cueText(c, srtEscaper))
+		}
+	case FormatVTT:
+		b.WriteString("WEBVTT\n\n")
+		for _, c := range cues {
+			fmt.Fprintf(&b, "%s --> %s\n%s\n\n", clock(c.Start, '.'), clock(c.End, '.'), cueText(c, vttEscaper))
+		}
+	}
+	return b.String()
+}
+
+// vttEscaper escapes WebVTT cue text: "&" and "<" start entities and tags,
+// and ">" is escaped so a literal "-->" cannot end up in the payload.
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// srtEscaper neutralises SRT cue text. SRT has no escape syntax: "-->" would
+// be read as a timing line, and players treat "<" as the start of a tag, so
+// both are replaced with look-alikes.
+var srtEscaper = strings.NewReplacer("-->", "->", "<", "‹")
+
+// cueText returns the cue's original line and translation, one per line,
+// escaped by esc. Text never contains a blank line, which would end the cue.
+func cueText(c Cue, esc *strings.Replacer) string {
+	text := esc.Replace(oneLine(c.Text))
+	if c.Translation == "" {
+		return text
+	}
+	return text + "\n" + esc.Replace(oneLine(c.Translation))
+}
+
+// oneLine folds any line breaks in s into spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// lrcTime formats d as mm:ss.xx.
+func lrcTime(d time.Duration) string {
+	cs := d.Milliseconds() / 10
+	return fmt.Sprintf("%02d:%02d.%02d", cs/6000, cs/100%60, cs%100)
+}
+
+// clock formats d as HH:MM:SS<sep>mmm (SRT uses ',', WebVTT '.').
+func clock(d time.Duration, sep byte) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
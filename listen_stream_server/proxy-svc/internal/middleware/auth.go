@@ -88,3 +88,37 @@ func parseUserToken(ctx context.Context, authHeader string, cfgSvc config.Servic
 	}
 	return claims, nil
 }
+
+// identityKey carries an already-authenticated identity in a request context.
+type identityKey struct{}
+
+type identity struct{ userID, deviceID, role, clientIP string }
+
+// WithIdentity returns a copy of ctx carrying the identity RequireUser set on c.
+// Used when proxy-svc dispatches internal sub-requests (POST /api/batch).
+func WithIdentity(ctx context.Context, c *gin.Context) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity{
+		userID:   c.GetString("user_id"),
+		deviceID: c.GetString("device_id"),
+		role:     c.GetString("role"),
+		clientIP: c.ClientIP(),
+	})
+}
+
+// TrustedIdentity restores an identity stored by WithIdentity. It is only
+// mounted on the internal sub-request router, never on a public listener;
+// requests without an identity are rejected.
+func TrustedIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := c.Request.Context().Value(identityKey{}).(identity)
+		if !ok || id.userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": "UNAUTHORIZED", "message": "missing identity"})
+			return
+		}
+		c.Set("user_id", id.userID)
+		c.Set("device_id", id.deviceID)
+		c.Set("role", id.role)
+		c.Set("client_ip", id.clientIP)
+		c.Next()
+	}
+}
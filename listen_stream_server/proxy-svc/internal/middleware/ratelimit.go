@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/config"
+	"listen-stream/shared/pkg/rdb"
+)
+
+// cfgRateLimits is a JSON object of token-bucket rules keyed by
+// "group:role", "group", "*:role" or "*" (most specific wins) for the per-user
+// bucket, and "group:ip" or "*:ip" for the per-client-IP bucket, e.g.
+//
+//	{"song": {"rate": 2, "burst": 10}, "song:ADMIN": {"rate": 5, "burst": 30}, "*:ip": {"rate": 20, "burst": 80}}
+//
+// rate is tokens per second; rate 0 disables limiting for that rule.
+// Keys not present fall back to defaultRateLimits.
+const cfgRateLimits = "RATE_LIMITS"
+
+// RateRule is one token-bucket rule.
+type RateRule struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// defaultRateLimits protects the endpoints that cost the most upstream quota
+// even when RATE_LIMITS has never been set. The IP bucket is looser than the
+// user one because several users may share an address (NAT, campus Wi-Fi).
+// "stream" is audio relay: players issue a Range request per seek and buffer
+// refill, so it gets more headroom than the rest of /api/song.
+var defaultRateLimits = map[string]RateRule{
+	"song":   {Rate: 2, Burst: 10},
+	"search": {Rate: 2, Burst: 10},
+	"stream": {Rate: 10, Burst: 60},
+	"*":      {Rate: 10, Burst: 40},
+	"*:ip":   {Rate: 30, Burst: 120},
+}
+
+// RateLimiter applies per-user and per-client-IP token buckets stored in
+// Redis, so the limits hold across all proxy-svc replicas.
+type RateLimiter struct {
+	rdb    *rdb.Client
+	cfgSvc config.Service
+	log    *zap.Logger
+
+	mu     sync.Mutex
+	raw    string // last RATE_LIMITS value parsed
+	parsed map[string]RateRule
+}
+
+// NewRateLimiter creates a RateLimiter.
+func NewRateLimiter(rdbClient *rdb.Client, cfgSvc config.Service, log *zap.Logger) *RateLimiter {
+	return &RateLimiter{rdb: rdbClient, cfgSvc: cfgSvc, log: log}
+}
+
+// Limit returns middleware that rate-limits group per client IP and, once
+// authenticated, per user: a request must fit both buckets. It runs after
+// RequireUser (it reads "user_id" and "role"); the IP comes from "client_ip"
+// when set (batch sub-requests) or the request itself; c.ClientIP only
+// honours X-Forwarded-For from the engine's TRUSTED_PROXIES.
+// Rejected requests get 429 with Retry-After, matching auth-svc's SMS limit.
+// Redis errors fail open: the limiter must never take the API down.
+func (l *RateLimiter) Limit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		ip := c.GetString("client_ip")
+		if ip == "" {
+			ip = c.ClientIP()
+		}
+		if !l.take(c, group, "ip:"+ip, l.rule(ctx, group, ipRole)) {
+			return
+		}
+		if user := c.GetString("user_id"); user != "" {
+			if !l.take(c, group, user, l.rule(ctx, group, c.GetString("role"))) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// take spends one token of subject's bucket in group. When the bucket is
+// empty it aborts c with 429 and returns false.
+func (l *RateLimiter) take(c *gin.Context, group, subject string, rule RateRule) bool {
+	if rule.Rate <= 0 {
+		return true
+	}
+	allowed, wait, err := l.rdb.TokenBucket(c.Request.Context(), rdb.KeyRateLimit(group, subject), rule.Rate, rule.Burst)
+	if err != nil {
+		l.log.Warn("rate limit check failed", zap.String("group", group), zap.Error(err))
+		return true
+	}
+	if !allowed {
+		retryAfter := int64(math.Ceil(wait.Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"code": "RATE_LIMITED", "retry_after": retryAfter})
+		return false
+	}
+	return true
+}
+
+// ipRole selects the per-IP rules ("group:ip", "*:ip") in rule.
+const ipRole = "ip"
+
+// rule resolves the most specific rule for (group, role). The IP bucket only
+// uses ip rules: a per-user rate is too tight for an address users share.
+func (l *RateLimiter) rule(ctx context.Context, group, role string) RateRule {
+	rules := l.rules(ctx)
+	keys := []string{group, "*"}
+	switch role {
+	case "":
+	case ipRole:
+		keys = []string{group + ":" + ipRole, "*:" + ipRole}
+	default:
+		keys = []string{group + ":" + role, group, "*:" + role, "*"}
+	}
+	for _, k := range keys {
+		if r, ok := rules[k]; ok {
+			return sanitize(r)
+		}
+		if r, ok := defaultRateLimits[k]; ok {
+			return r
+		}
+	}
+	return RateRule{}
+}
+
+// rules returns the parsed RATE_LIMITS value, re-parsing only when it changed.
+// An invalid value is logged once and ignored (defaults apply).
+func (l *RateLimiter) rules(ctx context.Context) map[string]RateRule {
+	v, _ := l.cfgSvc.Get(ctx, cfgRateLimits)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if v == l.raw && (l.parsed != nil || v == "") {
+		return l.parsed
+	}
+	l.raw, l.parsed = v, nil
+	if v == "" {
+		return nil
+	}
+	var m map[string]RateRule
+	if err := json.Unmarshal([]byte(v), &m); err != nil {
+		l.log.Warn("invalid RATE_LIMITS, using defaults", zap.Error(err))
+		l.parsed = map[string]RateRule{}
+		return l.parsed
+	}
+	l.parsed = m
+	return m
+}
+
+// sanitize fills a missing burst so a rule like {"rate": 5} still admits
+// one second's worth of requests at once.
+func sanitize(r RateRule) RateRule {
+	if r.Rate > 0 && r.Burst < 1 {
+		r.Burst = int(math.Max(1, math.Ceil(r.Rate)))
+	}
+	return r
+}
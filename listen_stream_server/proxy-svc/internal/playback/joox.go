@@ -0,0 +1,183 @@
+package playback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"listen-stream/proxy-svc/internal/catalog"
+	"listen-stream/proxy-svc/internal/upstream"
+	"listen-stream/shared/pkg/config"
+)
+
+// cfgJooxMatchThreshold is the minimum match confidence (0–1) a Joox search
+// hit needs to be played in place of the requested song.
+const (
+	cfgJooxMatchThreshold     = "JOOX_MATCH_THRESHOLD"
+	defaultJooxMatchThreshold = 0.7
+)
+
+// jooxBitrates maps a tier to the fallback API "br" parameter.
+var jooxBitrates = map[Quality]int{
+	QualityStandard: 128,
+	QualityHigh:     320,
+	QualityLossless: 999,
+}
+
+// JooxResolver finds the song on Joox through the fallback API.
+// It needs Request.Name because the fallback API can only search by name;
+// the artist, album and duration hints make the pick more reliable.
+type JooxResolver struct {
+	client *upstream.Client
+	cfgSvc config.Service
+	log    *zap.Logger
+}
+
+// NewJooxResolver creates a JooxResolver.
+func NewJooxResolver(client *upstream.Client, cfgSvc config.Service, log *zap.Logger) *JooxResolver {
+	return &JooxResolver{client: client, cfgSvc: cfgSvc, log: log}
+}
+
+// Name implements SourceResolver.
+func (r *JooxResolver) Name() string { return "joox" }
+
+// UsesHints implements HintDriven: which hit is played depends on the hints.
+func (r *JooxResolver) UsesHints() bool { return true }
+
+// Resolve searches Joox by song name and fetches the URL of the best hit.
+//
+//	Step a: types=search&source=joox&name=… → Joox id
+//	Step b: types=url&source=joox&id=…&br=… → playback URL
+//
+// Every search hit is scored against the request hints (see scoreCandidate);
+// when even the best scores below JOOX_MATCH_THRESHOLD, Resolve returns
+// ErrNoMatch rather than play a different song or a cover version.
+//
+// Joox silently downgrades tiers it lacks; a URL whose reported bitrate is
+// below the requested tier counts as ErrNoURL so the registry falls back.
+func (r *JooxResolver) Resolve(ctx context.Context, req Request) (string, error) {
+	if req.Name == "" {
+		return "", fmt.Errorf("%w: joox search needs name", ErrMissingHint)
+	}
+
+	// Step a: Search
+	searchQuery := fmt.Sprintf("types=search&source=joox&name=%s", url.QueryEscape(req.Name))
+	searchBody, err := r.client.DoFallback(ctx, "", searchQuery)
+	if err != nil {
+		return "", fmt.Errorf("joox search failed: %w", err)
+	}
+
+	songs, err := catalog.FromJooxSearch(searchBody)
+	if err != nil {
+		return "", fmt.Errorf("joox search parse failed: %w", err)
+	}
+	if len(songs) == 0 {
+		return "", fmt.Errorf("%w: joox search returned no results", ErrNoMatch)
+	}
+
+	jooxID, err := r.pick(ctx, req, songs)
+	if err != nil {
+		return "", err
+	}
+
+	// Step b: Get URL
+	br := jooxBitrates[req.Quality]
+	if br == 0 {
+		br = jooxBitrates[QualityStandard]
+	}
+	urlQuery := fmt.Sprintf("types=url&source=joox&id=%s&br=%d", url.QueryEscape(jooxID), br)
+	urlBody, err := r.client.DoFallback(ctx, "", urlQuery)
+	if err != nil {
+		return "", fmt.Errorf("joox url request failed: %w", err)
+	}
+
+	var urlResp struct {
+		URL string `json:"url"`
+		BR  int    `json:"br"`
+	}
+	if err := json.Unmarshal(urlBody, &urlResp); err != nil {
+		return "", fmt.Errorf("joox url parse failed: %w", err)
+	}
+
+	if urlResp.URL == "" {
+		return "", fmt.Errorf("%w: joox returned empty url", ErrNoURL)
+	}
+	if urlResp.BR > 0 && jooxQuality(urlResp.BR).rank() < req.Quality.rank() {
+		return "", fmt.Errorf("%w: joox returned br=%d for %s", ErrNoURL, urlResp.BR, req.Quality)
+	}
+	return urlResp.URL, nil
+}
+
+// pick returns the Joox id of the best-scoring candidate, or ErrNoMatch when
+// it is below the confidence threshold. The decision is logged either way.
+func (r *JooxResolver) pick(ctx context.Context, req Request, songs []catalog.Song) (string, error) {
+	best, runnerUp := -1, -1
+	scores := make([]matchScore, len(songs))
+	for i, s := range songs {
+		scores[i] = scoreCandidate(req, s)
+		switch {
+		case best < 0 || scores[i].Total > scores[best].Total:
+			best, runnerUp = i, best
+		case runnerUp < 0 || scores[i].Total > scores[runnerUp].Total:
+			runnerUp = i
+		}
+	}
+
+	threshold := r.threshold(ctx)
+	chosen := songs[best]
+	fields := []zap.Field{
+		zap.String("id", req.SongMID),
+		zap.String("want", req.Name),
+		zap.String("want_artist", req.Artist),
+		zap.String("candidate", chosen.MID),
+		zap.String("candidate_name", chosen.Name),
+		zap.Strings("candidate_artists", artistNames(chosen.Artists)),
+		zap.Stringer("score", scores[best]),
+		zap.Float64("threshold", threshold),
+		zap.Int("candidates", len(songs)),
+	}
+	if runnerUp >= 0 {
+		fields = append(fields, zap.Float64("runner_up_score", scores[runnerUp].Total))
+	}
+
+	if scores[best].Total < threshold {
+		r.log.Info("joox match rejected", fields...)
+		return "", fmt.Errorf("%w: best joox match %.2f below threshold %.2f", ErrNoMatch, scores[best].Total, threshold)
+	}
+	r.log.Info("joox match chosen", fields...)
+	return chosen.MID, nil
+}
+
+func (r *JooxResolver) threshold(ctx context.Context) float64 {
+	if v, err := r.cfgSvc.Get(ctx, cfgJooxMatchThreshold); err == nil {
+		if f, perr := strconv.ParseFloat(v, 64); perr == nil && f >= 0 && f <= 1 {
+			return f
+		}
+	}
+	return defaultJooxMatchThreshold
+}
+
+func artistNames(as []catalog.Artist) []string {
+	out := make([]string, len(as))
+	for i, a := range as {
+		out[i] = a.Name
+	}
+	return out
+}
+
+// jooxQuality classifies a bitrate reported by the fallback API. Lossless
+// streams report their average FLAC bitrate (typically 700–1000 kbps).
+func jooxQuality(br int) Quality {
+	switch {
+	case br >= 700:
+		return QualityLossless
+	case br >= 320:
+		return QualityHigh
+	default:
+		return QualityStandard
+	}
+}
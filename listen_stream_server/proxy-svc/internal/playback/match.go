@@ -0,0 +1,183 @@
+package playback
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"listen-stream/proxy-svc/internal/catalog"
+)
+
+// Weights of the match components. A component whose hint (or candidate
+// field) is missing is left out and the rest are renormalised, so a bare
+// name search is judged on the title alone.
+const (
+	weightTitle    = 0.5
+	weightArtist   = 0.3
+	weightAlbum    = 0.1
+	weightDuration = 0.1
+
+	// durationExact / durationMax: within durationExact seconds scores 1,
+	// falling linearly to 0 at durationMax.
+	durationExact = 3
+	durationMax   = 15
+
+	// versionPenalty is subtracted from the title score when a candidate is a
+	// live / cover / remix / … version the request did not ask for.
+	versionPenalty = 0.4
+)
+
+// versionMarkers flag alternative versions of a track in a title.
+var versionMarkers = []string{
+	"live", "cover", "remix", "instrumental", "karaoke", "acoustic", "dj",
+	"现场", "翻唱", "伴奏", "纯音乐", "混音", "演唱会",
+}
+
+// matchScore is a candidate's confidence and the components behind it.
+type matchScore struct {
+	Total    float64
+	Title    float64
+	Artist   float64 // -1 when not scored
+	Album    float64 // -1 when not scored
+	Duration float64 // -1 when not scored
+}
+
+// String renders the components for logs, e.g. "0.92 (title=1.00 artist=1.00 album=- duration=0.60)".
+func (m matchScore) String() string {
+	f := func(v float64) string {
+		if v < 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f", v)
+	}
+	return fmt.Sprintf("%.2f (title=%s artist=%s album=%s duration=%s)",
+		m.Total, f(m.Title), f(m.Artist), f(m.Album), f(m.Duration))
+}
+
+// scoreCandidate rates how likely cand is the song req describes.
+func scoreCandidate(req Request, cand catalog.Song) matchScore {
+	m := matchScore{Artist: -1, Album: -1, Duration: -1}
+	m.Title = titleScore(req.Name, cand.Name)
+	sum, weights := weightTitle*m.Title, weightTitle
+
+	if req.Artist != "" && len(cand.Artists) > 0 {
+		m.Artist = artistScore(req.Artist, cand.Artists)
+		sum, weights = sum+weightArtist*m.Artist, weights+weightArtist
+	}
+	if req.Album != "" && cand.Album != nil && cand.Album.Name != "" {
+		m.Album = similarity(normalizeTitle(req.Album), normalizeTitle(cand.Album.Name))
+		sum, weights = sum+weightAlbum*m.Album, weights+weightAlbum
+	}
+	if req.DurationSec > 0 && cand.DurationSec > 0 {
+		m.Duration = durationScore(req.DurationSec, cand.DurationSec)
+		sum, weights = sum+weightDuration*m.Duration, weights+weightDuration
+	}
+	m.Total = sum / weights
+	return m
+}
+
+// titleScore compares normalised titles, penalising an unrequested version.
+func titleScore(want, got string) float64 {
+	s := similarity(normalizeTitle(want), normalizeTitle(got))
+	wl, gl := strings.ToLower(want), strings.ToLower(got)
+	for _, mk := range versionMarkers {
+		if strings.Contains(gl, mk) && !strings.Contains(wl, mk) {
+			s -= versionPenalty
+			break
+		}
+	}
+	return max(s, 0)
+}
+
+// artistScore is 1 when any hinted artist equals a candidate artist, 0.5 when
+// one name contains the other, else 0. Hints may list several artists
+// separated by "/", ",", "&" or "、".
+func artistScore(hint string, artists []catalog.Artist) float64 {
+	best := 0.0
+	for _, h := range strings.FieldsFunc(hint, func(r rune) bool { return strings.ContainsRune("/,&、，", r) }) {
+		h = normalizeTitle(h)
+		if h == "" {
+			continue
+		}
+		for _, a := range artists {
+			n := normalizeTitle(a.Name)
+			switch {
+			case n == h:
+				return 1
+			case n != "" && (strings.Contains(n, h) || strings.Contains(h, n)):
+				best = 0.5
+			}
+		}
+	}
+	return best
+}
+
+func durationScore(want, got int) float64 {
+	d := want - got
+	if d < 0 {
+		d = -d
+	}
+	switch {
+	case d <= durationExact:
+		return 1
+	case d >= durationMax:
+		return 0
+	}
+	return float64(durationMax-d) / float64(durationMax-durationExact)
+}
+
+// normalizeTitle lowercases s, drops bracketed annotations ("(Live)",
+// "【MV】", "（伴奏）") and a trailing " - …" suffix, and keeps only letters
+// and digits, so punctuation and spacing differences do not count.
+func normalizeTitle(s string) string {
+	if i := strings.Index(s, " - "); i > 0 {
+		s = s[:i]
+	}
+	var b strings.Builder
+	depth := 0
+	for _, r := range strings.ToLower(s) {
+		switch r {
+		case '(', '[', '（', '【', '《', '{':
+			depth++
+			continue
+		case ')', ']', '）', '】', '》', '}':
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if depth == 0 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		// The whole title was bracketed; compare it as-is rather than as "".
+		return strings.ToLower(strings.TrimSpace(s))
+	}
+	return b.String()
+}
+
+// similarity is 1 - levenshtein(a, b) / max(len(a), len(b)), over runes.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
@@ -0,0 +1,223 @@
+package playback
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"listen-stream/proxy-svc/internal/catalog"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func artists(names ...string) []catalog.Artist {
+	out := make([]catalog.Artist, len(names))
+	for i, n := range names {
+		out[i] = catalog.Artist{Name: n}
+	}
+	return out
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"晴天", "晴天"},
+		{"晴天 (Live)", "晴天"},
+		{"晴天（伴奏）", "晴天"},
+		{"【MV】晴天", "晴天"},
+		{"Love Story - Taylor's Version", "lovestory"},
+		{"Don't Stop!", "dontstop"},
+		{"(Intro)", "(intro)"}, // fully bracketed: kept rather than ""
+	}
+	for _, tc := range cases {
+		if got := normalizeTitle(tc.in); got != tc.want {
+			t.Errorf("normalizeTitle(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestTitleScore(t *testing.T) {
+	cases := []struct {
+		name      string
+		want, got string
+		score     float64
+	}{
+		{"same", "晴天", "晴天", 1},
+		{"punctuation only", "Don't Stop", "Dont Stop!", 1},
+		{"live penalised", "晴天", "晴天 (Live)", 1 - versionPenalty},
+		{"remix penalised", "Faded", "Faded (Remix)", 1 - versionPenalty},
+		{"cjk marker penalised", "晴天", "晴天（伴奏）", 1 - versionPenalty},
+		{"version requested", "晴天 (Live)", "晴天 (Live)", 1},
+		{"penalty applied once", "晴天", "晴天 (Live Remix)", 1 - versionPenalty},
+		{"floored at zero", "abc", "xyz (live)", 0},
+		{"different song", "abcd", "abxy", 0.5},
+	}
+	for _, tc := range cases {
+		if got := titleScore(tc.want, tc.got); !near(got, tc.score) {
+			t.Errorf("%s: titleScore(%q, %q) = %.3f, want %.3f", tc.name, tc.want, tc.got, got, tc.score)
+		}
+	}
+}
+
+func TestArtistScore(t *testing.T) {
+	cases := []struct {
+		name  string
+		hint  string
+		cands []catalog.Artist
+		score float64
+	}{
+		{"exact", "周杰伦", artists("周杰伦"), 1},
+		{"case and spacing", "Taylor Swift", artists("taylorswift"), 1},
+		{"slash list", "周杰伦/杨瑞代", artists("杨瑞代"), 1},
+		{"comma list", "A, B", artists("B"), 1},
+		{"ampersand list", "Alan Walker & Ava Max", artists("Ava Max"), 1},
+		{"ideographic comma", "周杰伦、费玉清", artists("费玉清"), 1},
+		{"fullwidth comma", "周杰伦，费玉清", artists("费玉清"), 1},
+		{"candidate lists several", "费玉清", artists("周杰伦", "费玉清"), 1},
+		{"containment", "周杰伦", artists("周杰伦乐队"), 0.5},
+		{"exact beats containment", "周杰伦/周杰", artists("周杰伦乐队", "周杰"), 1},
+		{"no overlap", "周杰伦", artists("林俊杰"), 0},
+		{"only separators", "/,", artists("周杰伦"), 0},
+	}
+	for _, tc := range cases {
+		if got := artistScore(tc.hint, tc.cands); !near(got, tc.score) {
+			t.Errorf("%s: artistScore(%q) = %.2f, want %.2f", tc.name, tc.hint, got, tc.score)
+		}
+	}
+}
+
+func TestDurationScore(t *testing.T) {
+	cases := []struct {
+		want, got int
+		score     float64
+	}{
+		{200, 200, 1},
+		{200, 200 + durationExact, 1},
+		{200, 200 - durationExact, 1},
+		{200, 209, 0.5}, // halfway between durationExact and durationMax
+		{200, 200 + durationMax - 1, 1.0 / float64(durationMax-durationExact)},
+		{200, 200 + durationMax, 0},
+		{200, 400, 0},
+	}
+	for _, tc := range cases {
+		if got := durationScore(tc.want, tc.got); !near(got, tc.score) {
+			t.Errorf("durationScore(%d, %d) = %.3f, want %.3f", tc.want, tc.got, got, tc.score)
+		}
+	}
+}
+
+func TestScoreCandidate(t *testing.T) {
+	song := catalog.Song{
+		Name:        "晴天",
+		Artists:     artists("周杰伦"),
+		Album:       &catalog.Album{Name: "叶惠美"},
+		DurationSec: 269,
+	}
+	cases := []struct {
+		name string
+		req  Request
+		cand catalog.Song
+		want matchScore
+	}{
+		{
+			name: "every hint matches",
+			req:  Request{Name: "晴天", Artist: "周杰伦", Album: "叶惠美", DurationSec: 270},
+			cand: song,
+			want: matchScore{Total: 1, Title: 1, Artist: 1, Album: 1, Duration: 1},
+		},
+		{
+			name: "name only",
+			req:  Request{Name: "晴天"},
+			cand: song,
+			want: matchScore{Total: 1, Title: 1, Artist: -1, Album: -1, Duration: -1},
+		},
+		{
+			name: "candidate lacks album and duration",
+			req:  Request{Name: "晴天", Artist: "周杰伦", Album: "叶惠美", DurationSec: 269},
+			cand: catalog.Song{Name: "晴天", Artists: artists("周杰伦")},
+			want: matchScore{Total: 1, Title: 1, Artist: 1, Album: -1, Duration: -1},
+		},
+		{
+			name: "wrong artist, title and artist only",
+			req:  Request{Name: "晴天", Artist: "林俊杰"},
+			cand: song,
+			want: matchScore{
+				Total: weightTitle / (weightTitle + weightArtist),
+				Title: 1, Artist: 0, Album: -1, Duration: -1,
+			},
+		},
+		{
+			name: "live version of the right song",
+			req:  Request{Name: "晴天", Artist: "周杰伦", DurationSec: 269},
+			cand: catalog.Song{Name: "晴天 (Live)", Artists: artists("周杰伦"), DurationSec: 269},
+			want: matchScore{
+				Total: (weightTitle*(1-versionPenalty) + weightArtist + weightDuration) /
+					(weightTitle + weightArtist + weightDuration),
+				Title: 1 - versionPenalty, Artist: 1, Album: -1, Duration: 1,
+			},
+		},
+		{
+			name: "duration far off",
+			req:  Request{Name: "晴天", DurationSec: 200},
+			cand: song,
+			want: matchScore{
+				Total: weightTitle / (weightTitle + weightDuration),
+				Title: 1, Artist: -1, Album: -1, Duration: 0,
+			},
+		},
+	}
+	for _, tc := range cases {
+		got := scoreCandidate(tc.req, tc.cand)
+		if !near(got.Total, tc.want.Total) || !near(got.Title, tc.want.Title) || !near(got.Artist, tc.want.Artist) ||
+			!near(got.Album, tc.want.Album) || !near(got.Duration, tc.want.Duration) {
+			t.Errorf("%s: scoreCandidate = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// thresholdConfig sets JOOX_MATCH_THRESHOLD; every other key is unset.
+type thresholdConfig struct {
+	stubConfig
+	threshold float64
+}
+
+func (c thresholdConfig) Get(ctx context.Context, key string) (string, error) {
+	if key == cfgJooxMatchThreshold {
+		return strconv.FormatFloat(c.threshold, 'g', -1, 64), nil
+	}
+	return c.stubConfig.Get(ctx, key)
+}
+
+func TestPickThreshold(t *testing.T) {
+	req := Request{SongMID: "m", Name: "晴天", Artist: "周杰伦"}
+	songs := []catalog.Song{
+		{MID: "cover", Name: "晴天", Artists: artists("Cover Band")},
+		{MID: "live", Name: "晴天 (Live)", Artists: artists("周杰伦")},
+	}
+	best := scoreCandidate(req, songs[1]).Total
+	if other := scoreCandidate(req, songs[0]).Total; other >= best {
+		t.Fatalf("fixture: live (%.3f) should outscore the cover (%.3f)", best, other)
+	}
+
+	cases := []struct {
+		name      string
+		threshold float64
+		wantID    string
+		wantErr   error
+	}{
+		{"below threshold", best - 0.01, "live", nil},
+		{"at threshold", best, "live", nil},
+		{"just above", math.Nextafter(best, 1), "", ErrNoMatch},
+	}
+	for _, tc := range cases {
+		r := &JooxResolver{cfgSvc: thresholdConfig{threshold: tc.threshold}, log: zap.NewNop()}
+		id, err := r.pick(context.Background(), req, songs)
+		if id != tc.wantID || !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s (threshold %.4f, best %.4f): pick = %q, %v; want %q, %v",
+				tc.name, tc.threshold, best, id, err, tc.wantID, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package playback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"listen-stream/proxy-svc/internal/upstream"
+)
+
+// qqQualityTypes maps a tier to the upstream /song/url "type" parameter.
+// Standard sends no type, which yields the default C400 m4a stream.
+var qqQualityTypes = map[Quality]string{
+	QualityHigh:     "320",
+	QualityLossless: "flac",
+}
+
+// QQResolver asks the primary upstream for the QQ Music stream URL.
+type QQResolver struct {
+	client *upstream.Client
+}
+
+// NewQQResolver creates a QQResolver.
+func NewQQResolver(client *upstream.Client) *QQResolver {
+	return &QQResolver{client: client}
+}
+
+// Name implements SourceResolver.
+func (r *QQResolver) Name() string { return "qq" }
+
+// Resolve requests /song/url?id={mid}[&type=320|flac] and returns the URL
+// when code == 1.
+func (r *QQResolver) Resolve(ctx context.Context, req Request) (string, error) {
+	query := "id=" + url.QueryEscape(req.SongMID)
+	if t := qqQualityTypes[req.Quality]; t != "" {
+		query += "&type=" + t
+	}
+	body, err := r.client.Do(ctx, "/song/url", query)
+	if err != nil {
+		return "", fmt.Errorf("qq music request failed: %w", err)
+	}
+
+	var resp struct {
+		Code int `json:"code"`
+		Data struct {
+			URL string `json:"url"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("qq music parse failed: %w", err)
+	}
+
+	if resp.Code == 1 && resp.Data.URL != "" {
+		return resp.Data.URL, nil
+	}
+	return "", fmt.Errorf("%w: qq music returned code=%d", ErrNoURL, resp.Code)
+}
@@ -0,0 +1,97 @@
+package playback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Quality is an audio quality tier a client can ask for.
+type Quality string
+
+// Quality tiers, lowest first.
+const (
+	QualityStandard Quality = "standard" // ~128 kbps AAC/MP3
+	QualityHigh     Quality = "high"     // 320 kbps MP3
+	QualityLossless Quality = "lossless" // FLAC
+)
+
+// qualities lists every tier from lowest to highest.
+var qualities = []Quality{QualityStandard, QualityHigh, QualityLossless}
+
+// cfgPlaybackQualityPolicy is a JSON object mapping a user role to the
+// highest tier it may stream, e.g. {"ADMIN":"lossless","*":"high"}. Keys are
+// the JWT roles (USER, ADMIN, SUPER_ADMIN); "*" applies to roles without an
+// entry.
+const cfgPlaybackQualityPolicy = "PLAYBACK_QUALITY_POLICY"
+
+// defaultQualityPolicy applies when PLAYBACK_QUALITY_POLICY is unset or invalid.
+var defaultQualityPolicy = map[string]Quality{
+	"ADMIN":       QualityLossless,
+	"SUPER_ADMIN": QualityLossless,
+	"*":           QualityHigh,
+}
+
+// ParseQuality parses a client-supplied tier. Empty means QualityStandard.
+func ParseQuality(s string) (Quality, error) {
+	if s == "" {
+		return QualityStandard, nil
+	}
+	q := Quality(strings.ToLower(s))
+	if q.rank() < 0 {
+		return "", fmt.Errorf("unknown quality %q (want standard, high or lossless)", s)
+	}
+	return q, nil
+}
+
+// rank is q's position in qualities, or -1 for an unknown tier.
+func (q Quality) rank() int {
+	for i, t := range qualities {
+		if t == q {
+			return i
+		}
+	}
+	return -1
+}
+
+// Fallbacks returns q followed by every lower tier, i.e. the order in which
+// Registry.Resolve tries them.
+func (q Quality) Fallbacks() []Quality {
+	r := q.rank()
+	if r < 0 {
+		return []Quality{QualityStandard}
+	}
+	out := make([]Quality, 0, r+1)
+	for i := r; i >= 0; i-- {
+		out = append(out, qualities[i])
+	}
+	return out
+}
+
+// Grant caps want at the highest tier role is entitled to under
+// PLAYBACK_QUALITY_POLICY.
+func (r *Registry) Grant(ctx context.Context, role string, want Quality) Quality {
+	max := r.maxQuality(ctx, role)
+	if want.rank() > max.rank() {
+		return max
+	}
+	return want
+}
+
+func (r *Registry) maxQuality(ctx context.Context, role string) Quality {
+	policy := defaultQualityPolicy
+	if v, err := r.cfgSvc.Get(ctx, cfgPlaybackQualityPolicy); err == nil && strings.TrimSpace(v) != "" {
+		var p map[string]Quality
+		if err := json.Unmarshal([]byte(v), &p); err == nil {
+			policy = p
+		}
+	}
+	if q, ok := policy[role]; ok && q.rank() >= 0 {
+		return q
+	}
+	if q, ok := policy["*"]; ok && q.rank() >= 0 {
+		return q
+	}
+	return QualityStandard
+}
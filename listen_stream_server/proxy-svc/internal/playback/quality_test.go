@@ -0,0 +1,26 @@
+package playback
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGrantDefaultPolicy(t *testing.T) {
+	reg := NewRegistry(stubConfig{}, nil)
+	cases := []struct {
+		role  string
+		asked Quality
+		want  Quality
+	}{
+		{"USER", QualityLossless, QualityHigh},
+		{"USER", QualityStandard, QualityStandard},
+		{"ADMIN", QualityLossless, QualityLossless},
+		{"SUPER_ADMIN", QualityLossless, QualityLossless},
+		{"", QualityLossless, QualityHigh},
+	}
+	for _, tc := range cases {
+		if got := reg.Grant(context.Background(), tc.role, tc.asked); got != tc.want {
+			t.Errorf("Grant(%q, %s) = %s, want %s", tc.role, tc.asked, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package playback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxResolves bounds how many times one stream request walks the chain:
+	// the initial resolve plus re-resolves after 403/410 or a mid-body drop.
+	maxResolves = 3
+	// relayHeaderTimeout bounds the wait for the audio host's response headers.
+	// The body itself is unbounded: a song may take minutes to stream.
+	relayHeaderTimeout = 15 * time.Second
+	relayBufSize       = 32 << 10
+)
+
+// relayHeaders are copied from the audio host to the client. Everything else
+// (cookies, CDN debug headers, redirects) stays on our side.
+var relayHeaders = []string{
+	"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges",
+	"Last-Modified", "ETag", "Cache-Control",
+}
+
+// errExpired marks an audio-host response that means "this signed URL is no
+// longer valid" (403/410); the relay re-resolves instead of failing.
+var errExpired = errors.New("playback: signed url rejected")
+
+// Relay streams audio to the client through proxy-svc so the source URL never
+// leaves the server. It forwards Range requests, answers 206 when the audio
+// host does, and re-resolves the URL when the host rejects an expired one.
+type Relay struct {
+	sources *Registry
+	client  *http.Client
+	log     *zap.Logger
+}
+
+// NewRelay creates a Relay that resolves URLs through sources.
+func NewRelay(sources *Registry, log *zap.Logger) *Relay {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = relayHeaderTimeout
+	// Audio is already compressed; asking for gzip would also break Range offsets.
+	t.DisableCompression = true
+	return &Relay{sources: sources, client: &http.Client{Transport: t}, log: log}
+}
+
+// Serve relays req's audio to w, honouring r's Range header.
+//
+// A 403/410 from the audio host (signed URL expired between resolve and
+// fetch) triggers a fresh chain walk. If the host drops the connection after
+// the headers were sent, the relay resumes from the last byte written with a
+// new Range request on a freshly resolved URL of the same source and tier,
+// so the client sees one uninterrupted body. The resumed response must be
+// the same file: same total length and, when the host sends them, the same
+// ETag and Last-Modified; otherwise the relay stops rather than splice.
+func (rl *Relay) Serve(w http.ResponseWriter, r *http.Request, req Request) {
+	ctx := r.Context()
+	clientRange := r.Header.Get("Range")
+
+	resp, res, err := rl.open(ctx, req, nil, clientRange)
+	if err != nil {
+		if ctx.Err() == nil {
+			rl.log.Warn("stream open failed", zap.String("id", req.SongMID), zap.Error(err))
+		}
+		if errors.Is(err, ErrNoURL) {
+			writeError(w, http.StatusNotFound, "NO_SOURCE", "暂无播放权限")
+			return
+		}
+		writeError(w, http.StatusBadGateway, "UPSTREAM_ERROR", "stream unavailable")
+		return
+	}
+
+	for _, h := range relayHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	if w.Header().Get("Accept-Ranges") == "" {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	source := res.Source
+	start, end, total, resumable := bodyRange(resp)
+	first := resp.Header
+	var written int64
+	for attempt := 1; ; attempt++ {
+		n, rerr, werr := copyBody(w, resp.Body)
+		resp.Body.Close()
+		written += n
+		if rerr == nil || werr != nil || ctx.Err() != nil {
+			return // done, or the client went away
+		}
+		if !resumable || attempt >= maxResolves {
+			rl.log.Warn("stream interrupted", zap.String("id", req.SongMID),
+				zap.String("source", source), zap.Int64("written", written), zap.Error(rerr))
+			return
+		}
+
+		// Resume where the broken body left off.
+		resumeRange := fmt.Sprintf("bytes=%d-", start+written)
+		if end >= 0 {
+			resumeRange += strconv.FormatInt(end, 10)
+		}
+		resp, _, err = rl.open(ctx, req, &res, resumeRange)
+		if err != nil {
+			rl.log.Warn("stream resume failed", zap.String("id", req.SongMID),
+				zap.String("source", source), zap.Error(err))
+			return
+		}
+		s, _, t, ok := bodyRange(resp)
+		if !ok || resp.StatusCode != http.StatusPartialContent || s != start+written ||
+			(total >= 0 && t >= 0 && t != total) || !sameValidators(first, resp.Header) {
+			resp.Body.Close()
+			rl.log.Warn("stream resume returned a different range or file", zap.String("id", req.SongMID),
+				zap.String("source", source))
+			return
+		}
+	}
+}
+
+// open resolves req and sends a GET with rangeHdr, re-resolving up to
+// maxResolves times while the audio host answers 403/410. With pin set only
+// pin's source and tier are resolved (resume); otherwise the whole chain.
+func (rl *Relay) open(ctx context.Context, req Request, pin *Result, rangeHdr string) (*http.Response, Result, error) {
+	var lastErr error
+	for i := 0; i < maxResolves; i++ {
+		var res Result
+		if pin != nil {
+			pinned := req
+			pinned.Quality = pin.Quality
+			res = rl.sources.ResolveWith(ctx, pin.Source, pinned)
+		} else {
+			res = rl.sources.Resolve(ctx, req)
+		}
+		if res.URL == "" {
+			if lastErr == nil {
+				lastErr = ErrNoURL
+			}
+			return nil, Result{}, lastErr
+		}
+		resp, err := rl.get(ctx, res.URL, rangeHdr)
+		if err == nil {
+			return resp, res, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", res.Source, err)
+		if !errors.Is(err, errExpired) || ctx.Err() != nil {
+			return nil, Result{}, lastErr
+		}
+		rl.sources.Forget(ctx, req, res)
+		rl.log.Info("stream url rejected, re-resolving",
+			zap.String("id", req.SongMID), zap.String("source", res.Source), zap.Error(err))
+	}
+	return nil, Result{}, lastErr
+}
+
+// get fetches rawURL with an optional Range header. 200 and 206 are returned
+// to the caller (body open); 403/410 yield errExpired; anything else an error.
+func (rl *Relay) get(ctx context.Context, rawURL, rangeHdr string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rangeHdr != "" {
+		req.Header.Set("Range", rangeHdr)
+	}
+	resp, err := rl.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable:
+		return resp, nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("%w: status %d", errExpired, resp.StatusCode)
+	}
+	return nil, fmt.Errorf("audio host status %d", resp.StatusCode)
+}
+
+// bodyRange reports the absolute byte range carried by resp's body and the
+// file's total length (-1 when unknown). For 206 it parses
+// "Content-Range: bytes start-end/total"; for 200 the body starts at 0 and
+// runs to the end (-1). ok is false when the offset is unknown (e.g.
+// multipart ranges), which disables mid-body resume.
+func bodyRange(resp *http.Response) (start, end, total int64, ok bool) {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return 0, -1, resp.ContentLength, true
+	case http.StatusPartialContent:
+		cr := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+		span, size, found := strings.Cut(cr, "/")
+		if !found {
+			return 0, 0, 0, false
+		}
+		a, b, found := strings.Cut(span, "-")
+		if !found {
+			return 0, 0, 0, false
+		}
+		s, err1 := strconv.ParseInt(a, 10, 64)
+		e, err2 := strconv.ParseInt(b, 10, 64)
+		if err1 != nil || err2 != nil {
+			return 0, 0, 0, false
+		}
+		t, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			t = -1 // "*": length unknown
+		}
+		return s, e, t, true
+	}
+	return 0, 0, 0, false
+}
+
+// sameValidators reports whether two responses can be spliced: every
+// validator (ETag, Last-Modified) present on both must match.
+func sameValidators(a, b http.Header) bool {
+	for _, h := range []string{"ETag", "Last-Modified"} {
+		if va, vb := a.Get(h), b.Get(h); va != "" && vb != "" && va != vb {
+			return false
+		}
+	}
+	return true
+}
+
+// copyBody streams src to w, flushing after each chunk so the player can start
+// early. rerr is a read (audio host) error, werr a write (client) error.
+func copyBody(w http.ResponseWriter, src io.Reader) (n int64, rerr, werr error) {
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, relayBufSize)
+	for {
+		nr, err := src.Read(buf)
+		if nr > 0 {
+			nw, ew := w.Write(buf[:nr])
+			n += int64(nw)
+			if ew != nil {
+				return n, nil, ew
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return n, nil, nil
+		}
+		if err != nil {
+			return n, err, nil
+		}
+	}
+}
+
+// writeError writes the service's standard {"code", "message"} error body.
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"code": code, "message": msg})
+}
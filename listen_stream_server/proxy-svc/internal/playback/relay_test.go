@@ -0,0 +1,195 @@
+package playback
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/config"
+)
+
+// stubConfig is a config.Service with no keys set, so every setting uses
+// its default.
+type stubConfig struct{}
+
+func (stubConfig) Get(context.Context, string) (string, error) { return "", config.ErrConfigNotFound }
+func (stubConfig) GetMany(context.Context, []string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+func (stubConfig) Set(context.Context, string, string, string) error { return nil }
+func (stubConfig) Preload(context.Context) error                     { return nil }
+func (stubConfig) Invalidate(string)                                 {}
+
+// fileResolver resolves every request to url() and counts its calls.
+type fileResolver struct {
+	name  string
+	url   func(call int) string // "" means ErrNoURL
+	calls atomic.Int32
+}
+
+func (f *fileResolver) Name() string { return f.name }
+
+func (f *fileResolver) Resolve(context.Context, Request) (string, error) {
+	u := f.url(int(f.calls.Add(1)))
+	if u == "" {
+		return "", ErrNoURL
+	}
+	return u, nil
+}
+
+// audioHost stands in for a CDN: it serves files by path with Range support,
+// and cuts the connection of the first dropFirst responses after dropAfter
+// bytes.
+type audioHost struct {
+	files     map[string][]byte
+	etags     map[string]string
+	dropFirst int32
+	dropAfter int
+	served    atomic.Int32
+}
+
+func (a *audioHost) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, ok := a.files[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if etag := a.etags[r.URL.Path]; etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.Header().Set("Content-Type", "audio/mpeg")
+	if a.served.Add(1) <= a.dropFirst {
+		// Promise the whole file, send part of it, then drop the connection.
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data[:a.dropAfter])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+}
+
+func audioBytes(n int, seed byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i*7) + seed
+	}
+	return b
+}
+
+func newTestRelay(resolvers ...SourceResolver) *Relay {
+	return NewRelay(NewRegistry(stubConfig{}, nil, resolvers...), zap.NewNop())
+}
+
+func serve(rl *Relay, rangeHdr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/api/song/stream?id=m", nil)
+	if rangeHdr != "" {
+		r.Header.Set("Range", rangeHdr)
+	}
+	w := httptest.NewRecorder()
+	rl.Serve(w, r, Request{SongMID: "m", Name: "song", Quality: QualityStandard})
+	return w
+}
+
+func TestRelayRangePassthrough(t *testing.T) {
+	song := audioBytes(4096, 1)
+	host := httptest.NewServer(&audioHost{files: map[string][]byte{"/qq.mp3": song}})
+	defer host.Close()
+	qq := &fileResolver{name: "qq", url: func(int) string { return host.URL + "/qq.mp3" }}
+
+	w := serve(newTestRelay(qq), "bytes=100-199")
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", w.Code)
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes 100-199/4096" {
+		t.Errorf("Content-Range = %q", got)
+	}
+	if !bytes.Equal(w.Body.Bytes(), song[100:200]) {
+		t.Errorf("body is not bytes 100-199 of the file (%d bytes)", w.Body.Len())
+	}
+}
+
+func TestRelayResumesMidStream(t *testing.T) {
+	song := audioBytes(200_000, 1)
+	ah := &audioHost{
+		files:     map[string][]byte{"/qq.mp3": song},
+		etags:     map[string]string{"/qq.mp3": `"v1"`},
+		dropFirst: 1, dropAfter: 50_000,
+	}
+	host := httptest.NewServer(ah)
+	defer host.Close()
+	qq := &fileResolver{name: "qq", url: func(int) string { return host.URL + "/qq.mp3" }}
+
+	w := serve(newTestRelay(qq), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if !bytes.Equal(w.Body.Bytes(), song) {
+		t.Fatalf("body = %d bytes, want the whole %d-byte file", w.Body.Len(), len(song))
+	}
+	if n := ah.served.Load(); n != 2 {
+		t.Errorf("audio host requests = %d, want 2 (initial + resume)", n)
+	}
+}
+
+func TestRelayResumeStaysOnSource(t *testing.T) {
+	song := audioBytes(200_000, 1)
+	other := audioBytes(200_000, 99)
+	ah := &audioHost{
+		files:     map[string][]byte{"/qq.mp3": song, "/joox.mp3": other},
+		dropFirst: 1, dropAfter: 50_000,
+	}
+	host := httptest.NewServer(ah)
+	defer host.Close()
+	// QQ resolves once, then has no URL; Joox would serve a different file.
+	qq := &fileResolver{name: "qq", url: func(call int) string {
+		if call == 1 {
+			return host.URL + "/qq.mp3"
+		}
+		return ""
+	}}
+	joox := &fileResolver{name: "joox", url: func(int) string { return host.URL + "/joox.mp3" }}
+
+	w := serve(newTestRelay(qq, joox), "")
+	if got := w.Body.Len(); got != 50_000 {
+		t.Errorf("body = %d bytes, want the 50000 sent before the drop", got)
+	}
+	if !bytes.Equal(w.Body.Bytes(), song[:w.Body.Len()]) {
+		t.Error("body contains bytes from another file")
+	}
+	if n := joox.calls.Load(); n != 0 {
+		t.Errorf("joox resolved %d times during resume, want 0", n)
+	}
+}
+
+func TestRelayResumeRejectsDifferentFile(t *testing.T) {
+	song := audioBytes(200_000, 1)
+	reencoded := audioBytes(180_000, 1) // same source, new file behind the URL
+	ah := &audioHost{
+		files:     map[string][]byte{"/v1.mp3": song, "/v2.mp3": reencoded},
+		dropFirst: 1, dropAfter: 50_000,
+	}
+	host := httptest.NewServer(ah)
+	defer host.Close()
+	qq := &fileResolver{name: "qq", url: func(call int) string {
+		if call == 1 {
+			return host.URL + "/v1.mp3"
+		}
+		return host.URL + "/v2.mp3"
+	}}
+
+	w := serve(newTestRelay(qq), "")
+	if got := w.Body.Len(); got != 50_000 {
+		t.Errorf("body = %d bytes, want the 50000 sent before the drop", got)
+	}
+	if n := ah.served.Load(); n != 2 {
+		t.Errorf("audio host requests = %d, want 2", n)
+	}
+}
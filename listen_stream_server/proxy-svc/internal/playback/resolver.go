@@ -0,0 +1,294 @@
+// Package playback resolves a song into a playable stream URL by walking an
+// ordered chain of source resolvers (QQ Music, Joox, …).
+//
+// The handler never names a concrete source: it asks the Registry, which reads
+// the chain order from ConfigService and tries each enabled resolver in turn.
+// Adding a provider means implementing SourceResolver and registering it in
+// main.go; reordering or disabling one is a PLAYBACK_SOURCES config change.
+package playback
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"listen-stream/shared/pkg/config"
+	"listen-stream/shared/pkg/tracing"
+)
+
+// tracer records one span per resolver attempt in Resolve.
+var tracer = tracing.Tracer("listen-stream/proxy-svc/internal/playback")
+
+// cfgPlaybackSources is a comma-separated, ordered list of resolver names
+// (e.g. "qq,joox"). Unset or empty means "all registered, in registration order".
+const cfgPlaybackSources = "PLAYBACK_SOURCES"
+
+// ErrNoURL is returned by a resolver that reached its source but got no
+// playable URL back (no copyright, VIP-only track, empty search result, …).
+var ErrNoURL = errors.New("playback: no url available")
+
+// ErrNoMatch is an ErrNoURL from a search-based resolver that found no
+// confident match for the request hints. Unlike a plain ErrNoURL it says
+// more about the hints than about the song, so it is not negative-cached:
+// the next request may carry better ones.
+var ErrNoMatch = fmt.Errorf("%w: no confident match", ErrNoURL)
+
+// ErrMissingHint is returned by a resolver that cannot run without a hint the
+// caller did not supply (e.g. Joox needs the song name to search).
+var ErrMissingHint = errors.New("playback: required hint missing")
+
+// Request describes the song to resolve.
+// SongMID is always set; the remaining fields are optional hints that
+// search-based resolvers use to find the same track on another source.
+//
+// Quality is the highest tier to try; Registry.Resolve falls back through
+// the lower ones. A SourceResolver receives the single tier to fetch.
+type Request struct {
+	SongMID     string
+	Name        string
+	Artist      string // one or more names, e.g. "周杰伦" or "A/B"
+	Album       string
+	DurationSec int
+	Quality     Quality
+}
+
+// SourceResolver turns a Request into a playable URL from a single source.
+type SourceResolver interface {
+	// Name is the stable identifier used in PLAYBACK_SOURCES and in responses.
+	Name() string
+	// Resolve returns a playable URL in req.Quality, or an error describing
+	// why it could not (ErrNoURL when the source lacks that tier).
+	Resolve(ctx context.Context, req Request) (string, error)
+}
+
+// HintDriven is implemented by resolvers whose URL depends on the request's
+// hints rather than on SongMID alone, e.g. search-based sources. When
+// UsesHints reports true, the registry keys their URLCache entries by a hash
+// of the hints too, so one request's hints cannot pick the URL another
+// request for the same song is served.
+type HintDriven interface {
+	UsesHints() bool
+}
+
+// Attempt records the outcome of one resolver in the chain.
+type Attempt struct {
+	Source  string        `json:"source"`
+	Quality Quality       `json:"quality"`
+	Latency time.Duration `json:"latency"`
+	// Cached is true when the outcome came from the URLCache.
+	Cached bool `json:"cached,omitempty"`
+	// Reason is empty on success, otherwise the resolver's error text.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Result is the outcome of a full chain walk.
+// URL, Source and Quality are empty when every resolver failed.
+type Result struct {
+	URL      string
+	Source   string
+	Quality  Quality
+	Attempts []Attempt
+}
+
+// Registry holds the known resolvers and walks them in configured order.
+// It is safe for concurrent use.
+type Registry struct {
+	cfgSvc config.Service
+	cache  *URLCache // nil disables URL caching
+
+	mu        sync.RWMutex
+	resolvers map[string]SourceResolver
+	order     []string // registration order; the default chain
+}
+
+// NewRegistry creates a Registry with the given resolvers registered in order.
+// cache may be nil to resolve every request afresh.
+func NewRegistry(cfgSvc config.Service, cache *URLCache, resolvers ...SourceResolver) *Registry {
+	r := &Registry{
+		cfgSvc:    cfgSvc,
+		cache:     cache,
+		resolvers: make(map[string]SourceResolver, len(resolvers)),
+	}
+	for _, res := range resolvers {
+		r.Register(res)
+	}
+	return r
+}
+
+// Register adds res to the registry, replacing any resolver with the same name.
+// Newly named resolvers are appended to the default chain.
+func (r *Registry) Register(res SourceResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.resolvers[res.Name()]; !exists {
+		r.order = append(r.order, res.Name())
+	}
+	r.resolvers[res.Name()] = res
+}
+
+// Chain returns the resolvers to try, in order.
+// Names in PLAYBACK_SOURCES that are not registered are ignored.
+func (r *Registry) Chain(ctx context.Context) []SourceResolver {
+	var names []string
+	if v, err := r.cfgSvc.Get(ctx, cfgPlaybackSources); err == nil && strings.TrimSpace(v) != "" {
+		names = strings.Split(v, ",")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if names == nil {
+		names = r.order
+	}
+	chain := make([]SourceResolver, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if res, ok := r.resolvers[n]; ok && !seen[n] {
+			chain = append(chain, res)
+			seen[n] = true
+		}
+	}
+	return chain
+}
+
+// Resolve walks the chain until a resolver returns a URL, once per tier from
+// req.Quality down to QualityStandard: a lower tier from any source is only
+// tried after every source failed the higher one.
+// Every resolver tried is recorded in Result.Attempts, successful or not.
+//
+// With a URLCache, each (source, tier) outcome is looked up before the
+// resolver runs: URLs are cached until their signed expiry, and ErrNoURL
+// answers briefly. The key holds the song and tier, plus a hash of the hints
+// for HintDriven resolvers. Other errors (timeouts, missing hints,
+// ErrNoMatch) are not cached.
+func (r *Registry) Resolve(ctx context.Context, req Request) Result {
+	var res Result
+	chain := r.Chain(ctx)
+	for _, q := range req.Quality.Fallbacks() {
+		tierReq := req
+		tierReq.Quality = q
+		for _, s := range chain {
+			start := time.Now()
+			sctx, span := tracer.Start(ctx, "playback "+s.Name(), trace.WithAttributes(
+				attribute.String("playback.source", s.Name()),
+				attribute.String("playback.quality", string(q)),
+			))
+			u, cached, err := r.resolve(sctx, s, tierReq)
+			span.SetAttributes(attribute.Bool("playback.cached", cached))
+			if err != nil {
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+			a := Attempt{Source: s.Name(), Quality: q, Latency: time.Since(start), Cached: cached}
+			if err != nil {
+				a.Reason = err.Error()
+				res.Attempts = append(res.Attempts, a)
+				if ctx.Err() != nil {
+					return res
+				}
+				continue
+			}
+			res.Attempts = append(res.Attempts, a)
+			res.URL = u
+			res.Source = s.Name()
+			res.Quality = q
+			return res
+		}
+	}
+	return res
+}
+
+// ResolveWith resolves req from the named source at exactly req.Quality:
+// no other source or tier is tried. The relay uses it to resume a stream on
+// the same file it started on, since another source or tier serves different
+// bytes. An unknown source yields an empty Result.
+func (r *Registry) ResolveWith(ctx context.Context, source string, req Request) Result {
+	r.mu.RLock()
+	s, ok := r.resolvers[source]
+	r.mu.RUnlock()
+	if !ok {
+		return Result{}
+	}
+	start := time.Now()
+	u, cached, err := r.resolve(ctx, s, req)
+	a := Attempt{Source: source, Quality: req.Quality, Latency: time.Since(start), Cached: cached}
+	if err != nil {
+		a.Reason = err.Error()
+		return Result{Attempts: []Attempt{a}}
+	}
+	return Result{URL: u, Source: source, Quality: req.Quality, Attempts: []Attempt{a}}
+}
+
+// Forget drops the cached URL behind res, e.g. when the audio host rejected
+// it as expired, so the next Resolve asks the source again.
+func (r *Registry) Forget(ctx context.Context, req Request, res Result) {
+	if r.cache == nil || res.URL == "" {
+		return
+	}
+	r.mu.RLock()
+	s, ok := r.resolvers[res.Source]
+	r.mu.RUnlock()
+	key := res.Source
+	if ok {
+		key = cacheSource(s, req)
+	}
+	r.cache.forget(ctx, key, req.SongMID, res.Quality)
+}
+
+// resolve runs one resolver through the URL cache. An empty URL is reported
+// as ErrNoURL.
+func (r *Registry) resolve(ctx context.Context, s SourceResolver, req Request) (u string, cached bool, err error) {
+	source := cacheSource(s, req)
+	if r.cache != nil {
+		if u, ok := r.cache.get(ctx, source, req.SongMID, req.Quality); ok {
+			if u == "" {
+				return "", true, fmt.Errorf("%w (cached)", ErrNoURL)
+			}
+			return u, true, nil
+		}
+	}
+	u, err = s.Resolve(ctx, req)
+	if err == nil && u == "" {
+		err = ErrNoURL
+	}
+	if r.cache != nil && ctx.Err() == nil {
+		switch {
+		case err == nil:
+			r.cache.put(ctx, source, req.SongMID, req.Quality, u)
+		case negativeCacheable(err):
+			r.cache.putMiss(ctx, source, req.SongMID, req.Quality)
+		}
+	}
+	return u, false, err
+}
+
+// cacheSource is the source part of s's URLCache key for req: the name, with
+// a hash of the hints appended for HintDriven resolvers.
+func cacheSource(s SourceResolver, req Request) string {
+	if h, ok := s.(HintDriven); ok && h.UsesHints() {
+		return s.Name() + "~" + hintHash(req)
+	}
+	return s.Name()
+}
+
+// hintHash identifies the hints of req: the first 8 bytes of SHA-256 over
+// name, artist, album and duration, hex-encoded.
+func hintHash(req Request) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d", req.Name, req.Artist, req.Album, req.DurationSec)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// negativeCacheable reports whether err is a "no URL" answer about the song
+// itself, as opposed to one that depends on the request's hints.
+func negativeCacheable(err error) bool {
+	return errors.Is(err, ErrNoURL) && !errors.Is(err, ErrNoMatch)
+}
@@ -0,0 +1,54 @@
+package playback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNegativeCacheable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{ErrNoURL, true},
+		{fmt.Errorf("%w: qq music returned code=104003", ErrNoURL), true},
+		{ErrNoMatch, false},
+		{fmt.Errorf("%w: best joox match 0.40 below threshold 0.70", ErrNoMatch), false},
+		{fmt.Errorf("%w: joox search needs name", ErrMissingHint), false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, tc := range cases {
+		if got := negativeCacheable(tc.err); got != tc.want {
+			t.Errorf("negativeCacheable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+	if !errors.Is(ErrNoMatch, ErrNoURL) {
+		t.Error("ErrNoMatch must still be an ErrNoURL for callers")
+	}
+}
+
+func TestCacheSource(t *testing.T) {
+	joox := &JooxResolver{}
+	req := Request{SongMID: "mid", Name: "晴天", Artist: "周杰伦", DurationSec: 269}
+
+	if got := cacheSource(NewQQResolver(nil), req); got != "qq" {
+		t.Errorf("qq cache source = %q, want the bare name", got)
+	}
+	base := cacheSource(joox, req)
+	if !strings.HasPrefix(base, "joox~") {
+		t.Fatalf("joox cache source = %q, want a hint hash", base)
+	}
+	other := req
+	other.Artist = "Cover Band"
+	if cacheSource(joox, other) == base {
+		t.Error("different hints share a joox cache entry")
+	}
+	other = req
+	other.Quality = QualityLossless
+	if cacheSource(joox, other) != base {
+		t.Error("quality changed the hint hash; it is keyed separately")
+	}
+}
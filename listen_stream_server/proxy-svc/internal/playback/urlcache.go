@@ -0,0 +1,138 @@
+package playback
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/config"
+	"listen-stream/shared/pkg/rdb"
+)
+
+const (
+	// cfgPlaybackURLTTL caps how long a resolved URL is cached, in seconds, and
+	// is used as-is for URLs that carry no expiry parameter. 0 disables caching.
+	cfgPlaybackURLTTL     = "PLAYBACK_URL_TTL"
+	defaultPlaybackURLTTL = 10 * time.Minute
+
+	// cfgPlaybackNegativeTTL is how long, in seconds, a source's "no URL"
+	// answer is remembered. 0 disables negative caching.
+	cfgPlaybackNegativeTTL     = "PLAYBACK_NEGATIVE_TTL"
+	defaultPlaybackNegativeTTL = time.Minute
+
+	// urlExpiryMargin is subtracted from a signed URL's expiry so a cached URL
+	// is never handed out moments before the audio host starts rejecting it.
+	urlExpiryMargin = time.Minute
+)
+
+// urlExpiryParams are query parameters audio hosts use for an absolute Unix
+// expiry time (seconds, or milliseconds for some CDNs).
+var urlExpiryParams = []string{"Expires", "expires", "expire", "expiry", "deadline", "e"}
+
+// URLCache remembers resolved stream URLs in Redis per source, song and
+// quality (rdb.KeyPlaybackURL), so replays and second devices skip the
+// resolver chain. Errors are logged and treated as a miss; the cache never
+// fails a resolve.
+type URLCache struct {
+	rdb    *rdb.Client
+	cfgSvc config.Service
+	log    *zap.Logger
+}
+
+// NewURLCache creates a URLCache.
+func NewURLCache(rdbClient *rdb.Client, cfgSvc config.Service, log *zap.Logger) *URLCache {
+	return &URLCache{rdb: rdbClient, cfgSvc: cfgSvc, log: log}
+}
+
+// get returns the cached URL and true on a hit. A hit with an empty URL is a
+// cached "no URL" answer.
+func (c *URLCache) get(ctx context.Context, source, songMID string, q Quality) (string, bool) {
+	v, err := c.rdb.Get(ctx, rdb.KeyPlaybackURL(source, songMID, string(q)))
+	if err != nil {
+		return "", false // miss, or Redis unavailable
+	}
+	return v, true
+}
+
+// put caches u until its signed expiry minus urlExpiryMargin, capped at
+// PLAYBACK_URL_TTL. URLs expiring within the margin are not cached.
+func (c *URLCache) put(ctx context.Context, source, songMID string, q Quality, u string) {
+	ttl := c.seconds(ctx, cfgPlaybackURLTTL, defaultPlaybackURLTTL)
+	if ttl <= 0 {
+		return
+	}
+	if exp, ok := urlExpiry(u); ok {
+		if left := time.Until(exp) - urlExpiryMargin; left < ttl {
+			ttl = left
+		}
+	}
+	if ttl < time.Second {
+		return
+	}
+	c.set(ctx, source, songMID, q, u, ttl)
+}
+
+// putMiss caches that source has no URL for the song at q.
+func (c *URLCache) putMiss(ctx context.Context, source, songMID string, q Quality) {
+	if ttl := c.seconds(ctx, cfgPlaybackNegativeTTL, defaultPlaybackNegativeTTL); ttl > 0 {
+		c.set(ctx, source, songMID, q, "", ttl)
+	}
+}
+
+// forget drops a cached URL, e.g. after the audio host rejected it.
+func (c *URLCache) forget(ctx context.Context, source, songMID string, q Quality) {
+	if err := c.rdb.Del(ctx, rdb.KeyPlaybackURL(source, songMID, string(q))); err != nil {
+		c.log.Warn("playback url cache delete failed", zap.String("source", source), zap.Error(err))
+	}
+}
+
+func (c *URLCache) set(ctx context.Context, source, songMID string, q Quality, u string, ttl time.Duration) {
+	if err := c.rdb.Set(ctx, rdb.KeyPlaybackURL(source, songMID, string(q)), u, ttl); err != nil {
+		c.log.Warn("playback url cache write failed", zap.String("source", source), zap.Error(err))
+	}
+}
+
+// seconds reads an integer-seconds config value, falling back to def.
+func (c *URLCache) seconds(ctx context.Context, key string, def time.Duration) time.Duration {
+	if v, err := c.cfgSvc.Get(ctx, key); err == nil {
+		if n, perr := strconv.Atoi(v); perr == nil && n >= 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return def
+}
+
+// urlExpiry extracts the absolute expiry a signed URL carries, either as an
+// S3-style X-Amz-Date + X-Amz-Expires pair or as one of urlExpiryParams.
+func urlExpiry(raw string) (time.Time, bool) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	q := u.Query()
+
+	if d, s := q.Get("X-Amz-Date"), q.Get("X-Amz-Expires"); d != "" && s != "" {
+		signed, err1 := time.Parse("20060102T150405Z", d)
+		secs, err2 := strconv.ParseInt(s, 10, 64)
+		if err1 == nil && err2 == nil {
+			return signed.Add(time.Duration(secs) * time.Second), true
+		}
+	}
+
+	for _, p := range urlExpiryParams {
+		n, err := strconv.ParseInt(q.Get(p), 10, 64)
+		if err != nil || n <= 0 {
+			continue
+		}
+		if n > 1e12 {
+			return time.UnixMilli(n), true
+		}
+		if n > 1e9 { // anything smaller is not a plausible Unix time
+			return time.Unix(n, 0), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,201 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"listen-stream/shared/pkg/rdb"
+)
+
+const (
+	// cfgBreakerThreshold is the number of consecutive failures that opens a breaker.
+	cfgBreakerThreshold     = "UPSTREAM_BREAKER_THRESHOLD"
+	defaultBreakerThreshold = 5
+
+	// cfgBreakerCooldown is how many seconds a breaker stays open before it
+	// lets a single half-open probe through.
+	cfgBreakerCooldown     = "UPSTREAM_BREAKER_COOLDOWN"
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned when every candidate base URL has an open breaker.
+var ErrCircuitOpen = errors.New("upstream: circuit open")
+
+// BreakerState is the state of one base URL's circuit breaker.
+type BreakerState string
+
+const (
+	// StateClosed lets all traffic through and counts consecutive failures.
+	StateClosed BreakerState = "closed"
+	// StateOpen rejects traffic until the cooldown elapses.
+	StateOpen BreakerState = "open"
+	// StateHalfOpen lets exactly one probe through; its result closes or re-opens.
+	StateHalfOpen BreakerState = "half_open"
+)
+
+// BreakerStatus is a point-in-time snapshot of one breaker, as published to
+// Redis (rdb.KeyUpstreamBreakers) for admin-svc.
+type BreakerStatus struct {
+	BaseURL   string       `json:"base_url"`
+	State     BreakerState `json:"state"`
+	Failures  int          `json:"failures"`
+	OpenedAt  *time.Time   `json:"opened_at,omitempty"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Instance  string       `json:"instance"`
+}
+
+// breakerSettings holds the thresholds read from ConfigService for one call.
+type breakerSettings struct {
+	threshold int
+	cooldown  time.Duration
+}
+
+func parseBreakerSettings(keys map[string]string) breakerSettings {
+	s := breakerSettings{threshold: defaultBreakerThreshold, cooldown: defaultBreakerCooldown}
+	if n, err := strconv.Atoi(keys[cfgBreakerThreshold]); err == nil && n > 0 {
+		s.threshold = n
+	}
+	if n, err := strconv.Atoi(keys[cfgBreakerCooldown]); err == nil && n > 0 {
+		s.cooldown = time.Duration(n) * time.Second
+	}
+	return s
+}
+
+// breaker is a consecutive-failure circuit breaker for a single base URL.
+type breaker struct {
+	baseURL string
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool // a half-open probe is in flight
+}
+
+func newBreaker(baseURL string) *breaker {
+	return &breaker{baseURL: baseURL, state: StateClosed}
+}
+
+// allow reports whether a request may be sent now. An open breaker whose
+// cooldown has elapsed moves to half-open and admits one probe.
+// changed is true when the call caused a state transition.
+func (b *breaker) allow(s breakerSettings) (ok, changed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < s.cooldown {
+			return false, false
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return true, true
+	case StateHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, false
+	default:
+		return true, false
+	}
+}
+
+// record feeds the outcome of an admitted request back into the breaker.
+// changed is true when the call caused a state transition.
+func (b *breaker) record(success bool, s breakerSettings) (changed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	prev := b.state
+	b.probing = false
+	if success {
+		b.failures = 0
+		b.state = StateClosed
+		return prev != StateClosed
+	}
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= s.threshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+	return prev != b.state
+}
+
+// abandon releases an admitted request's half-open probe slot without
+// recording an outcome (the caller gave up before the endpoint answered).
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// status returns a snapshot of the breaker.
+func (b *breaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := BreakerStatus{
+		BaseURL:   b.baseURL,
+		State:     b.state,
+		Failures:  b.failures,
+		UpdatedAt: time.Now().UTC(),
+		Instance:  instanceName,
+	}
+	if b.state != StateClosed {
+		t := b.openedAt.UTC()
+		st.OpenedAt = &t
+	}
+	return st
+}
+
+// instanceName identifies this replica in published breaker snapshots.
+var instanceName = func() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return h
+}()
+
+// breakerSet lazily creates one breaker per base URL.
+type breakerSet struct {
+	rdb *rdb.Client
+
+	mu sync.Mutex
+	m  map[string]*breaker
+}
+
+func newBreakerSet(rdbClient *rdb.Client) *breakerSet {
+	return &breakerSet{rdb: rdbClient, m: make(map[string]*breaker)}
+}
+
+func (s *breakerSet) get(baseURL string) *breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.m[baseURL]
+	if !ok {
+		b = newBreaker(baseURL)
+		s.m[baseURL] = b
+	}
+	return b
+}
+
+// publish writes b's snapshot to Redis (best effort) so admin-svc can show it.
+// Each replica keeps its own breakers, so the field is per instance and URL;
+// a shared field would only ever show the replica that wrote last.
+func (s *breakerSet) publish(b *breaker) {
+	if s.rdb == nil {
+		return
+	}
+	data, err := json.Marshal(b.status())
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.rdb.HSet(ctx, rdb.KeyUpstreamBreakers(), rdb.UpstreamBreakerField(instanceName, b.baseURL), string(data))
+}
@@ -3,77 +3,271 @@ package upstream
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
 	"listen-stream/shared/pkg/config"
+	"listen-stream/shared/pkg/rdb"
+	"listen-stream/shared/pkg/tracing"
+	"listen-stream/shared/pkg/upstreams"
 )
 
+// tracer wraps Do and DoFallback in a span; each HTTP send beneath it gets a
+// client span from the transport. Trace headers are not sent upstream.
+var tracer = tracing.Tracer("listen-stream/proxy-svc/internal/upstream")
+
+// endSpan marks span failed when err is set, then ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 const (
 	cfgAPIBaseURL     = "API_BASE_URL"
 	cfgAPIFallbackURL = "API_FALLBACK_URL"
 	cfgAPIKey         = "API_KEY"
 )
 
+// StatusError is returned when upstream answers with a non-2xx status.
+type StatusError struct {
+	Code int
+	Path string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("upstream: status %d for %s", e.Code, e.Path)
+}
+
+// poolConfigKeys are read from ConfigService by the probe loop (see
+// refreshConfig), not per request.
+var poolConfigKeys = []string{
+	upstreams.ConfigKey, cfgAPIBaseURL, cfgAPIFallbackURL, cfgAPIKey,
+	cfgBreakerThreshold, cfgBreakerCooldown, cfgCookie, cfgCookiePool,
+	cfgAuthExpiredMessages,
+}
+
+// poolConfig is a parsed snapshot of poolConfigKeys. It is replaced whole,
+// never modified.
+type poolConfig struct {
+	eps         []*endpoint
+	fallbackURL string
+	settings    breakerSettings
+	cookies     []string
+	authMsgs    []string // see authMessages
+}
+
 // Client forwards requests to the upstream music API.
-// Base URL, Fallback URL and API Key are read from ConfigService on each call
-// (30 s cache ensures freshness without per-request DB hits).
-// If primary URL fails, automatically retries with fallback URL if configured.
+//
+// Requests are spread over a pool of endpoints (UPSTREAM_ENDPOINTS, each with
+// its own URL, API key and weight; see package upstreams), or over the legacy
+// API_BASE_URL + API_FALLBACK_URL pair when no pool is configured. Start runs
+// background health probes; healthy endpoints share traffic in proportion to
+// weight ÷ observed latency, and a failed request moves on to the next
+// endpoint.
+//
+// Each base URL has its own circuit breaker: after UPSTREAM_BREAKER_THRESHOLD
+// consecutive failures it opens and the URL is skipped for
+// UPSTREAM_BREAKER_COOLDOWN seconds, so traffic goes straight to the healthy
+// endpoint instead of burning the client timeout on every request.
+//
+// Requests carry the upstream login cookie (COOKIE, or COOKIE_POOL rotated
+// per request). A response saying the cookie was rejected marks it bad,
+// raises the cookie alert and retries on the next endpoint with the next
+// cookie. Once every configured cookie is rejected, requests go out without
+// one, except on cookiePaths, which fail with ErrNoCookie (apart from
+// periodic rechecks); see cookieJar.
 type Client struct {
-	cfgSvc config.Service
-	cli    *http.Client
+	cfgSvc   config.Service
+	cli      *http.Client
+	breakers *breakerSet
+	pool     *pool
+	cookies  *cookieJar
+	log      *zap.Logger
+
+	snapshot atomic.Pointer[poolConfig] // set by refreshConfig
 }
 
-// New creates an upstream Client.
-func New(cfgSvc config.Service) *Client {
+// New creates an upstream Client. Breaker transitions (rdb.KeyUpstreamBreakers)
+// and probe results (rdb.KeyUpstreamPool) are published to rdbClient for
+// admin-svc. Call Start to begin health probing.
+func New(cfgSvc config.Service, rdbClient *rdb.Client, log *zap.Logger) *Client {
 	return &Client{
-		cfgSvc: cfgSvc,
-		cli:    &http.Client{Timeout: 10 * time.Second},
+		cfgSvc:   cfgSvc,
+		cli:      &http.Client{Timeout: 10 * time.Second, Transport: tracing.ExternalTransport(nil)},
+		breakers: newBreakerSet(rdbClient),
+		pool:     newPool(rdbClient, log),
+		cookies:  newCookieJar(rdbClient, log),
+		log:      log,
 	}
 }
 
-// Do sends a GET request to {baseURL}{path}?{rawQuery} with the configured
+// Do sends a GET request to {endpoint}{path}?{rawQuery} with the endpoint's
 // API key in Authorization Bearer header and returns the response body bytes.
-// If primary URL fails and fallback URL is configured, retries with fallback.
-func (c *Client) Do(ctx context.Context, path, rawQuery string) ([]byte, error) {
-	keys, err := c.cfgSvc.GetMany(ctx, []string{cfgAPIBaseURL, cfgAPIFallbackURL, cfgAPIKey})
+// Endpoints are tried in pool order (see order) until one answers, up to
+// maxAttempts sends; the first real error is returned if all fail.
+func (c *Client) Do(ctx context.Context, path, rawQuery string) (body []byte, err error) {
+	ctx, span := tracer.Start(ctx, "upstream "+path, trace.WithAttributes(attribute.String("upstream.path", path)))
+	defer func() { endSpan(span, err) }()
+
+	cfg, err := c.config(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("upstream: read config: %w", err)
+		return nil, err
+	}
+	eps := cfg.eps
+	if len(eps) == 0 {
+		return nil, fmt.Errorf("upstream: base URL not configured")
 	}
 
-	// Try primary URL first
-	body, err := c.doRequest(ctx, keys[cfgAPIBaseURL], keys[cfgAPIKey], path, rawQuery)
-	if err != nil {
-		// If primary fails and fallback is configured, try fallback
-		if fallbackURL := keys[cfgAPIFallbackURL]; fallbackURL != "" {
-			body, fallbackErr := c.doRequest(ctx, fallbackURL, keys[cfgAPIKey], path, rawQuery)
-			if fallbackErr == nil {
-				return body, nil
+	var firstErr error
+	sent := 0
+	for _, e := range order(eps) {
+		if sent == maxAttempts {
+			break
+		}
+		start := time.Now()
+		cookie, err := c.cookies.pick(cfg.cookies)
+		if err != nil {
+			if cookiePaths[path] {
+				if firstErr == nil || errors.Is(firstErr, ErrCircuitOpen) {
+					firstErr = err
+				}
+				break
 			}
-			// Return original error if fallback also fails
+			cookie = "" // the endpoint works without one
+		}
+		body, err := c.guarded(ctx, e.URL, cfg.settings, func() ([]byte, error) {
+			return c.doRequest(ctx, e.URL, e.APIKey, cookie, cfg.authMsgs, path, rawQuery)
+		})
+		if errors.Is(err, ErrAuthExpired) {
+			c.cookies.markBad(ctx, cookie, path)
+		}
+		if err != nil {
+			span.AddEvent("endpoint failed", trace.WithAttributes(
+				attribute.String("upstream.endpoint", e.Name), attribute.String("error", err.Error())))
+		}
+		if err == nil {
+			span.SetAttributes(attribute.String("upstream.endpoint", e.Name), attribute.Int("upstream.attempts", sent+1))
+			e.observe(time.Since(start))
+			if cookie != "" {
+				c.cookies.markGood(ctx, cookie)
+			}
+			if t, ok := ctx.Value(traceKey{}).(*Trace); ok {
+				t.BaseURL = e.URL
+			}
+			return body, nil
+		}
+		if !errors.Is(err, ErrCircuitOpen) {
+			sent++
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// Report the first endpoint's error if the others also fail.
+		if firstErr == nil || errors.Is(firstErr, ErrCircuitOpen) {
+			firstErr = err
 		}
-		return nil, err
 	}
-	return body, nil
+	return nil, firstErr
+}
+
+// Trace records which base URL answered a Do call. See WithTrace.
+type Trace struct {
+	BaseURL string
+}
+
+type traceKey struct{}
+
+// WithTrace returns a context that makes Do fill in the returned Trace.
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	t := &Trace{}
+	return context.WithValue(ctx, traceKey{}, t), t
 }
 
 // DoFallback sends a GET request directly to the fallback API URL.
 // Used for Joox search and URL retrieval.
 // Path parameter is typically empty as query contains full request (e.g., "types=search&source=joox&name=...").
-func (c *Client) DoFallback(ctx context.Context, path, rawQuery string) ([]byte, error) {
-	keys, err := c.cfgSvc.GetMany(ctx, []string{cfgAPIFallbackURL})
+func (c *Client) DoFallback(ctx context.Context, path, rawQuery string) (body []byte, err error) {
+	ctx, span := tracer.Start(ctx, "upstream fallback", trace.WithAttributes(attribute.String("upstream.path", path)))
+	defer func() { endSpan(span, err) }()
+
+	cfg, err := c.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.fallbackURL == "" {
+		return nil, fmt.Errorf("upstream: fallback URL not configured")
+	}
+
+	return c.guarded(ctx, cfg.fallbackURL, cfg.settings, func() ([]byte, error) {
+		return c.doRequestRaw(ctx, cfg.fallbackURL, path, rawQuery)
+	})
+}
+
+// config returns the current pool config snapshot, reading it on the first
+// call if the probe loop has not run yet.
+func (c *Client) config(ctx context.Context) (*poolConfig, error) {
+	if cfg := c.snapshot.Load(); cfg != nil {
+		return cfg, nil
+	}
+	return c.refreshConfig(ctx)
+}
+
+// refreshConfig reads poolConfigKeys and publishes a new snapshot. The probe
+// loop calls it every UPSTREAM_PROBE_INTERVAL, so config edits reach request
+// traffic within one interval (plus the ConfigService cache window).
+func (c *Client) refreshConfig(ctx context.Context) (*poolConfig, error) {
+	keys, err := c.cfgSvc.GetMany(ctx, poolConfigKeys)
 	if err != nil {
 		return nil, fmt.Errorf("upstream: read config: %w", err)
 	}
+	cfg := &poolConfig{
+		eps:         c.pool.endpoints(keys),
+		fallbackURL: keys[cfgAPIFallbackURL],
+		settings:    parseBreakerSettings(keys),
+		cookies:     cookiePool(keys),
+		authMsgs:    authMessages(keys),
+	}
+	c.snapshot.Store(cfg)
+	return cfg, nil
+}
 
-	fallbackURL := keys[cfgAPIFallbackURL]
-	if fallbackURL == "" {
-		return nil, fmt.Errorf("upstream: fallback URL not configured")
+// guarded runs send through baseURL's circuit breaker.
+// Transport errors and 5xx count as failures; 4xx and a rejected cookie mean
+// the endpoint is healthy and the request itself was bad. A cancelled caller context is
+// not held against the endpoint.
+func (c *Client) guarded(ctx context.Context, baseURL string, s breakerSettings, send func() ([]byte, error)) ([]byte, error) {
+	b := c.breakers.get(baseURL)
+	ok, changed := b.allow(s)
+	if changed {
+		c.breakers.publish(b)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, baseURL)
 	}
 
-	return c.doRequestRaw(ctx, fallbackURL, path, rawQuery)
+	body, err := send()
+	if err != nil && ctx.Err() != nil {
+		b.abandon()
+		return nil, err
+	}
+	var se *StatusError
+	healthy := err == nil || (errors.As(err, &se) && se.Code < 500) || errors.Is(err, ErrAuthExpired)
+	if b.record(healthy, s) {
+		c.breakers.publish(b)
+	}
+	return body, err
 }
 
 // doRequestRaw performs HTTP request without API key (for fallback API).
@@ -93,7 +287,7 @@ func (c *Client) doRequestRaw(ctx context.Context, baseURL, path, rawQuery strin
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("upstream: status %d", resp.StatusCode)
+		return nil, &StatusError{Code: resp.StatusCode, Path: path}
 	}
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20)) // 4 MB cap
 	if err != nil {
@@ -102,8 +296,10 @@ func (c *Client) doRequestRaw(ctx context.Context, baseURL, path, rawQuery strin
 	return body, nil
 }
 
-// doRequest performs actual HTTP request with given base URL
-func (c *Client) doRequest(ctx context.Context, baseURL, apiKey, path, rawQuery string) ([]byte, error) {
+// doRequest performs actual HTTP request with given base URL. When cookie is
+// set it is sent as the Cookie header, and a response rejecting it (see
+// authExpired) yields ErrAuthExpired.
+func (c *Client) doRequest(ctx context.Context, baseURL, apiKey, cookie string, authMsgs []string, path, rawQuery string) ([]byte, error) {
 	if baseURL == "" {
 		return nil, fmt.Errorf("upstream: base URL not configured")
 	}
@@ -118,18 +314,24 @@ func (c *Client) doRequest(ctx context.Context, baseURL, apiKey, path, rawQuery
 	if apiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+apiKey)
 	}
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
 	req.Header.Set("User-Agent", "listen-stream/1.0")
 	resp, err := c.cli.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("upstream: http: %w", err)
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("upstream: status %d for %s", resp.StatusCode, path)
-	}
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20)) // 4 MB cap
 	if err != nil {
 		return nil, fmt.Errorf("upstream: read body: %w", err)
 	}
+	if cookie != "" && authExpired(body, authMsgs) {
+		return nil, fmt.Errorf("%w: status %d for %s", ErrAuthExpired, resp.StatusCode, path)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &StatusError{Code: resp.StatusCode, Path: path}
+	}
 	return body, nil
 }
@@ -0,0 +1,68 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/config"
+)
+
+// mapConfig is a config.Service serving fixed values.
+type mapConfig map[string]string
+
+func (m mapConfig) Get(_ context.Context, key string) (string, error) {
+	if v, ok := m[key]; ok {
+		return v, nil
+	}
+	return "", config.ErrConfigNotFound
+}
+
+func (m mapConfig) GetMany(_ context.Context, keys []string) (map[string]string, error) {
+	out := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+func (mapConfig) Set(context.Context, string, string, string) error { return nil }
+func (mapConfig) Preload(context.Context) error                     { return nil }
+func (mapConfig) Invalidate(string)                                 {}
+
+// TestDoWhenEveryCookieRejected checks that a rejected COOKIE only fails the
+// cookie-bound paths; everything else goes out without a cookie.
+func TestDoWhenEveryCookieRejected(t *testing.T) {
+	var cookies []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		_, _ = w.Write([]byte(`{"code": 1, "message": "Success", "data": []}`))
+	}))
+	defer api.Close()
+
+	c := New(mapConfig{"API_BASE_URL": api.URL, "COOKIE": "uin=1"}, nil, zap.NewNop())
+	ctx := context.Background()
+	c.cookies.markBad(ctx, "uin=1", "/recommend/daily")
+
+	if _, err := c.Do(ctx, "/recommend/banner", ""); err != nil {
+		t.Fatalf("banner: %v", err)
+	}
+	if len(cookies) != 1 || cookies[0] != "" {
+		t.Errorf("banner sent cookies %q, want one request without a cookie", cookies)
+	}
+
+	for _, path := range []string{"/recommend/daily", "/song/url"} {
+		if _, err := c.Do(ctx, path, "id=1"); !errors.Is(err, ErrNoCookie) {
+			t.Errorf("%s: err = %v, want ErrNoCookie", path, err)
+		}
+	}
+	if len(cookies) != 1 {
+		t.Errorf("cookie-bound paths reached upstream: %q", cookies[1:])
+	}
+}
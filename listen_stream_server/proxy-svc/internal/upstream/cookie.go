@@ -0,0 +1,245 @@
+package upstream
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/rdb"
+)
+
+const (
+	// cfgCookie is the upstream login cookie maintained by sync-svc's cookie cron.
+	cfgCookie = "COOKIE"
+	// cfgCookiePool optionally lists several cookies (JSON array of strings,
+	// or one per line) to rotate through per request. It takes precedence
+	// over COOKIE when non-empty.
+	cfgCookiePool = "COOKIE_POOL"
+	// cfgAuthExpiredMessages lists the messages (JSON array of strings, or
+	// one per line) of upstream's cookie-rejected envelope; see
+	// authExpiredMessages for the default.
+	cfgAuthExpiredMessages = "UPSTREAM_AUTH_EXPIRED_MESSAGES"
+
+	// cookieBadTTL is how long a rejected cookie is skipped. A refreshed
+	// cookie has a new fingerprint and is used straight away.
+	cookieBadTTL = 24 * time.Hour
+	// cookieRecheck is how often, once every configured cookie is rejected,
+	// one request is let through with the longest-untried of them in case
+	// upstream accepts it again.
+	cookieRecheck = 5 * time.Minute
+	// cookieAlertTTL matches the alert TTL set by sync-svc's cookie cron.
+	cookieAlertTTL = 24 * time.Hour
+)
+
+// ErrAuthExpired is returned by Do when upstream rejected the cookie the
+// request carried (login expired or revoked).
+var ErrAuthExpired = errors.New("upstream: cookie rejected")
+
+// ErrNoCookie is returned by Do, without sending, for a cookiePaths request
+// when cookies are configured but upstream has rejected every one of them:
+// without a cookie those endpoints answer for an anonymous user, which
+// should not pass for a normal response while the alert is outstanding.
+// Every other path is sent without a cookie.
+var ErrNoCookie = errors.New("upstream: every configured cookie was rejected")
+
+// cookiePaths are the upstream paths api.md documents as needing the login
+// cookie.
+var cookiePaths = map[string]bool{
+	"/recommend/daily": true,
+	"/song/url":        true,
+}
+
+// authExpiredMessages is the default for UPSTREAM_AUTH_EXPIRED_MESSAGES: the
+// messages of upstream's cookie-rejected envelope, {"code": 0, "message": …},
+// compared case-insensitively.
+//
+// UNCONFIRMED: api.md and the fixtures do not document this envelope, and no
+// real rejected-cookie response has been captured. The entry below is only
+// the placeholder cmd/mockupstream serves; against the real upstream it most
+// likely never matches, so rejections go undetected until the actual message
+// is set in UPSTREAM_AUTH_EXPIRED_MESSAGES. Keep entries exact: other code-0
+// failures, such as "VIP-only content or not available in your region",
+// concern the song.
+var authExpiredMessages = []string{
+	"login expired, cookie invalid",
+}
+
+// cookieJar picks the cookie for each upstream request and remembers which
+// ones upstream has rejected. Each replica detects rejections on its own;
+// the Redis record (rdb.KeyCookieBad, rdb.KeyCookieAlert) is for admin-svc.
+type cookieJar struct {
+	rdb *rdb.Client
+	log *zap.Logger
+
+	next atomic.Uint64 // round-robin position in the pool
+
+	mu  sync.Mutex
+	bad map[string]*badCookie // by fingerprint
+}
+
+// badCookie is a rejected cookie's skip period and last recheck.
+type badCookie struct {
+	until time.Time // skip until
+	tried time.Time // last time it was sent anyway; see cookieRecheck
+}
+
+func newCookieJar(rdbClient *rdb.Client, log *zap.Logger) *cookieJar {
+	return &cookieJar{rdb: rdbClient, log: log, bad: make(map[string]*badCookie)}
+}
+
+// cookiePool returns the configured cookies: COOKIE_POOL when non-empty,
+// else COOKIE on its own.
+func cookiePool(keys map[string]string) []string {
+	cookies := parseList(keys[cfgCookiePool])
+	if len(cookies) == 0 && keys[cfgCookie] != "" {
+		cookies = []string{keys[cfgCookie]}
+	}
+	return cookies
+}
+
+// pick returns the cookie for the next request: the next usable entry of
+// cookies (see cookiePool) in rotation, or "" when none is configured.
+// When every configured cookie has been rejected it returns ErrNoCookie,
+// except that every cookieRecheck one request gets the longest-untried
+// cookie; if upstream accepts it, markGood puts it back in rotation.
+func (j *cookieJar) pick(cookies []string) (string, error) {
+	if len(cookies) == 0 {
+		return "", nil
+	}
+	now := time.Now()
+	start := j.next.Add(1)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var recheck *badCookie
+	cookie := ""
+	for i := range cookies {
+		c := cookies[(start+uint64(i))%uint64(len(cookies))]
+		b, ok := j.bad[cookieFingerprint(c)]
+		if !ok || !now.Before(b.until) {
+			return c, nil
+		}
+		if recheck == nil || b.tried.Before(recheck.tried) {
+			recheck, cookie = b, c
+		}
+	}
+	if now.Sub(recheck.tried) < cookieRecheck {
+		return "", ErrNoCookie
+	}
+	recheck.tried = now
+	return cookie, nil
+}
+
+// markGood puts cookie back in rotation if it was marked bad, i.e. after a
+// recheck request carrying it succeeded.
+func (j *cookieJar) markGood(ctx context.Context, cookie string) {
+	fp := cookieFingerprint(cookie)
+	j.mu.Lock()
+	_, ok := j.bad[fp]
+	delete(j.bad, fp)
+	j.mu.Unlock()
+	if !ok {
+		return
+	}
+	j.log.Info("upstream accepted cookie again", zap.String("fingerprint", fp))
+	if j.rdb != nil {
+		_ = j.rdb.Del(context.WithoutCancel(ctx), rdb.KeyCookieBad(fp))
+	}
+}
+
+// markBad takes cookie out of rotation and raises the cookie alert at once,
+// rather than leaving it to the next cookie cron tick.
+func (j *cookieJar) markBad(ctx context.Context, cookie, path string) {
+	fp := cookieFingerprint(cookie)
+	at := time.Now()
+	j.mu.Lock()
+	if b, ok := j.bad[fp]; ok && at.Before(b.until) {
+		j.mu.Unlock()
+		return // already reported
+	}
+	j.bad[fp] = &badCookie{until: at.Add(cookieBadTTL), tried: at}
+	j.mu.Unlock()
+
+	j.log.Warn("upstream rejected cookie", zap.String("fingerprint", fp), zap.String("path", path))
+	if j.rdb == nil {
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	ctx = context.WithoutCancel(ctx)
+	_ = j.rdb.Set(ctx, rdb.KeyCookieBad(fp), now, cookieBadTTL)
+	alert, _ := json.Marshal(map[string]string{
+		"event":       "cookie_rejected",
+		"fingerprint": fp,
+		"path":        path,
+		"time":        now,
+		"instance":    instanceName,
+	})
+	if err := j.rdb.Set(ctx, rdb.KeyCookieAlert(), string(alert), cookieAlertTTL); err != nil {
+		j.log.Error("set cookie alert", zap.Error(err))
+	}
+}
+
+// authMessages returns UPSTREAM_AUTH_EXPIRED_MESSAGES, or
+// authExpiredMessages when it is unset or empty.
+func authMessages(keys map[string]string) []string {
+	if msgs := parseList(keys[cfgAuthExpiredMessages]); len(msgs) > 0 {
+		return msgs
+	}
+	return authExpiredMessages
+}
+
+// parseList accepts a JSON array of strings or one entry per line.
+func parseList(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	var list []string
+	if strings.HasPrefix(raw, "[") {
+		if err := json.Unmarshal([]byte(raw), &list); err != nil {
+			return nil
+		}
+	} else {
+		list = strings.Split(raw, "\n")
+	}
+	out := list[:0]
+	for _, c := range list {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// cookieFingerprint identifies a cookie in logs and Redis without exposing it.
+func cookieFingerprint(cookie string) string {
+	sum := sha256.Sum256([]byte(cookie))
+	return hex.EncodeToString(sum[:8])
+}
+
+// authExpired reports whether an upstream response body is the
+// cookie-rejected envelope: code 0 with one of messages. A 401 or 403 is not
+// taken as one: it may just as well be the endpoint refusing its API key.
+func authExpired(body []byte, messages []string) bool {
+	var env struct {
+		Code    *int   `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &env) != nil || env.Code == nil || *env.Code != 0 {
+		return false
+	}
+	msg := strings.TrimSpace(env.Message)
+	for _, m := range messages {
+		if strings.EqualFold(msg, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestAuthExpired(t *testing.T) {
+	cases := []struct {
+		body string
+		want bool
+	}{
+		{`{"code": 0, "message": "login expired, cookie invalid", "data": []}`, true},
+		{`{"code": 0, "message": "Login Expired, Cookie Invalid "}`, true},
+		// The documented /song/url failure concerns the song, not the cookie.
+		{`{"code": 0, "message": "VIP-only content or not available in your region", "data": []}`, false},
+		{`{"code": 0, "message": "cookie expired? no: token for other login"}`, false},
+		{`{"code": 1, "message": "login expired, cookie invalid"}`, false},
+		{`{"message": "login expired, cookie invalid"}`, false},
+		{`{"code": 1, "message": "Success", "data": {}}`, false},
+		{`<html>401 Unauthorized</html>`, false},
+		{``, false},
+	}
+	for _, tc := range cases {
+		if got := authExpired([]byte(tc.body), authExpiredMessages); got != tc.want {
+			t.Errorf("authExpired(%s) = %v, want %v", tc.body, got, tc.want)
+		}
+	}
+
+	// UPSTREAM_AUTH_EXPIRED_MESSAGES replaces the placeholder default.
+	msgs := authMessages(map[string]string{cfgAuthExpiredMessages: `["请重新登录"]`})
+	if !authExpired([]byte(`{"code": 0, "message": "请重新登录"}`), msgs) {
+		t.Error("configured message not matched")
+	}
+	if authExpired([]byte(`{"code": 0, "message": "login expired, cookie invalid"}`), msgs) {
+		t.Error("default message still matched after override")
+	}
+	if got := authMessages(map[string]string{}); len(got) != 1 || got[0] != authExpiredMessages[0] {
+		t.Errorf("unset: authMessages = %q, want the default", got)
+	}
+}
+
+func TestCookiePick(t *testing.T) {
+	ctx := context.Background()
+	j := newCookieJar(nil, zap.NewNop())
+
+	if c, err := j.pick(nil); c != "" || err != nil {
+		t.Fatalf("pick(nil) = %q, %v; want no cookie, no error", c, err)
+	}
+
+	pool := []string{"a=1", "b=2", "c=3"}
+	seen := map[string]int{}
+	for range 6 {
+		c, err := j.pick(pool)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[c]++
+	}
+	if len(seen) != 3 || seen["a=1"] != 2 {
+		t.Errorf("rotation = %v, want each cookie twice", seen)
+	}
+
+	j.markBad(ctx, "b=2", "/recommend/daily")
+	for range 6 {
+		if c, _ := j.pick(pool); c == "b=2" {
+			t.Fatal("picked a cookie marked bad")
+		}
+	}
+
+	j.markBad(ctx, "a=1", "/recommend/daily")
+	j.markBad(ctx, "c=3", "/recommend/daily")
+	if c, err := j.pick(pool); !errors.Is(err, ErrNoCookie) || c != "" {
+		t.Fatalf("all rejected: pick = %q, %v; want ErrNoCookie", c, err)
+	}
+
+	// After cookieRecheck, one request retries the longest-untried cookie.
+	j.mu.Lock()
+	j.bad[cookieFingerprint("b=2")].tried = time.Now().Add(-2 * cookieRecheck)
+	j.bad[cookieFingerprint("c=3")].tried = time.Now().Add(-cookieRecheck - time.Second)
+	j.mu.Unlock()
+	if c, err := j.pick(pool); err != nil || c != "b=2" {
+		t.Fatalf("recheck: pick = %q, %v; want b=2", c, err)
+	}
+	if c, _ := j.pick(pool); c != "c=3" {
+		t.Fatalf("second recheck: pick = %q, want c=3", c)
+	}
+	if _, err := j.pick(pool); !errors.Is(err, ErrNoCookie) {
+		t.Fatalf("rechecks used up: err = %v, want ErrNoCookie", err)
+	}
+
+	// A recheck that succeeds puts the cookie back in rotation.
+	j.markGood(ctx, "b=2")
+	if c, err := j.pick(pool); err != nil || c != "b=2" {
+		t.Fatalf("after markGood: pick = %q, %v; want b=2", c, err)
+	}
+}
@@ -0,0 +1,352 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"listen-stream/shared/pkg/rdb"
+	"listen-stream/shared/pkg/upstreams"
+)
+
+const (
+	// cfgProbeInterval is how often, in seconds, every pool endpoint is probed.
+	cfgProbeInterval     = "UPSTREAM_PROBE_INTERVAL"
+	defaultProbeInterval = 15 * time.Second
+
+	// probePath is a cheap, always-available upstream endpoint.
+	probePath    = "/recommend/banner"
+	probeTimeout = 5 * time.Second
+	// unhealthyAfter consecutive failed probes take an endpoint out of rotation;
+	// one successful probe puts it back.
+	unhealthyAfter = 2
+
+	// latencyAlpha weighs a new latency sample in the moving average.
+	latencyAlpha = 0.3
+	// defaultLatency stands in for endpoints with no sample yet.
+	defaultLatency = 200 * time.Millisecond
+	// minLatency stops a near-zero sample from claiming all traffic.
+	minLatency = 10 * time.Millisecond
+
+	// maxAttempts bounds how many endpoints one Do call sends to; endpoints
+	// skipped by an open breaker do not count.
+	maxAttempts = 3
+)
+
+// endpoint is a pool member and its observed health. The embedded
+// definition is read-only; mu guards the rest.
+type endpoint struct {
+	upstreams.Endpoint
+
+	mu         sync.Mutex
+	healthy    bool // true until probes say otherwise
+	probeFails int
+	latency    time.Duration // moving average; 0 until the first sample
+	lastProbe  time.Time
+	lastErr    string
+}
+
+// observe folds a successful request or probe latency into the average.
+func (e *endpoint) observe(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.latency == 0 {
+		e.latency = d
+		return
+	}
+	e.latency = time.Duration(latencyAlpha*float64(d) + (1-latencyAlpha)*float64(e.latency))
+}
+
+// probed records a probe outcome. changed reports a health transition.
+func (e *endpoint) probed(d time.Duration, err error) (changed bool) {
+	e.mu.Lock()
+	was := e.healthy
+	e.lastProbe = time.Now()
+	if err != nil {
+		e.probeFails++
+		e.lastErr = err.Error()
+		if e.probeFails >= unhealthyAfter {
+			e.healthy = false
+		}
+		e.mu.Unlock()
+		return was && !e.healthy
+	}
+	e.probeFails = 0
+	e.lastErr = ""
+	e.healthy = true
+	e.mu.Unlock()
+	e.observe(d)
+	return !was
+}
+
+// score is the endpoint's relative attractiveness: weight per second of
+// latency, 0 when it should not get first-choice traffic.
+func (e *endpoint) score() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.healthy || e.Weight == 0 {
+		return 0
+	}
+	lat := e.latency
+	if lat == 0 {
+		lat = defaultLatency
+	}
+	return float64(e.Weight) / max(lat, minLatency).Seconds()
+}
+
+// pool holds the endpoints built from the current UPSTREAM_ENDPOINTS value.
+// Endpoints keep their health and latency across config reloads as long as
+// their name and URL are unchanged.
+type pool struct {
+	rdb *rdb.Client
+	log *zap.Logger
+
+	mu         sync.Mutex
+	defs       []upstreams.Endpoint
+	eps        []*endpoint
+	badConfig  string // last invalid UPSTREAM_ENDPOINTS value, logged once
+	publishing sync.Mutex
+}
+
+func newPool(rdbClient *rdb.Client, log *zap.Logger) *pool {
+	return &pool{rdb: rdbClient, log: log}
+}
+
+// endpoints returns the pool for the given config values: UPSTREAM_ENDPOINTS
+// when set and valid, otherwise the legacy API_BASE_URL / API_FALLBACK_URL
+// pair. An invalid UPSTREAM_ENDPOINTS is logged once and ignored.
+func (p *pool) endpoints(keys map[string]string) []*endpoint {
+	defs, err := upstreams.Parse(keys[upstreams.ConfigKey])
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		if raw := keys[upstreams.ConfigKey]; raw != p.badConfig {
+			p.badConfig = raw
+			p.log.Error("invalid UPSTREAM_ENDPOINTS, using API_BASE_URL", zap.Error(err))
+		}
+		defs = nil
+	}
+	if defs == nil {
+		defs = upstreams.Legacy(keys[cfgAPIBaseURL], keys[cfgAPIFallbackURL], keys[cfgAPIKey])
+	}
+	if equalDefs(defs, p.defs) {
+		return p.eps
+	}
+
+	prev := make(map[string]*endpoint, len(p.eps))
+	for _, e := range p.eps {
+		prev[e.Name+"\x00"+e.URL] = e
+	}
+	// Endpoint fields are immutable once published (Do reads them unlocked),
+	// so a changed definition gets a new endpoint carrying the old stats.
+	eps := make([]*endpoint, 0, len(defs))
+	for _, d := range defs {
+		e := &endpoint{Endpoint: d, healthy: true}
+		if old, ok := prev[d.Name+"\x00"+d.URL]; ok {
+			old.mu.Lock()
+			e.healthy, e.probeFails, e.latency = old.healthy, old.probeFails, old.latency
+			e.lastProbe, e.lastErr = old.lastProbe, old.lastErr
+			old.mu.Unlock()
+		}
+		eps = append(eps, e)
+	}
+	p.defs, p.eps = defs, eps
+	return eps
+}
+
+func equalDefs(a, b []upstreams.Endpoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// order returns eps in the order one request should try them:
+//
+//  1. healthy weighted endpoints, as a random permutation in which each
+//     endpoint's chance of coming first is proportional to its score
+//     (weight ÷ latency);
+//  2. healthy backups (weight 0), fastest first;
+//  3. unhealthy endpoints, as a last resort in case the probes are stale.
+func order(eps []*endpoint) []*endpoint {
+	type ranked struct {
+		e   *endpoint
+		key float64
+	}
+	var primary, backup, down []ranked
+	for _, e := range eps {
+		e.mu.Lock()
+		healthy, lat := e.healthy, e.latency
+		e.mu.Unlock()
+		switch {
+		case !healthy:
+			down = append(down, ranked{e: e})
+		case e.Weight == 0:
+			backup = append(backup, ranked{e: e, key: -float64(lat)})
+		default:
+			// Weighted random sampling without replacement (Efraimidis–Spirakis):
+			// sorting by u^(1/score) descending draws each next item with
+			// probability proportional to its score.
+			primary = append(primary, ranked{e: e, key: math.Pow(rand.Float64(), 1/e.score())})
+		}
+	}
+	byKey := func(r []ranked) {
+		sort.SliceStable(r, func(i, j int) bool { return r[i].key > r[j].key })
+	}
+	byKey(primary)
+	byKey(backup)
+
+	out := make([]*endpoint, 0, len(eps))
+	for _, group := range [][]ranked{primary, backup, down} {
+		for _, r := range group {
+			out = append(out, r.e)
+		}
+	}
+	return out
+}
+
+// statuses snapshots eps for publishing.
+func statuses(eps []*endpoint) []upstreams.Status {
+	var total float64
+	scores := make([]float64, len(eps))
+	for i, e := range eps {
+		scores[i] = e.score()
+		total += scores[i]
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	out := make([]upstreams.Status, len(eps))
+	for i, e := range eps {
+		e.mu.Lock()
+		st := upstreams.Status{
+			Name:      e.Name,
+			URL:       e.URL,
+			Weight:    e.Weight,
+			Healthy:   e.healthy,
+			LatencyMS: e.latency.Milliseconds(),
+			LastError: e.lastErr,
+			Instance:  instanceName,
+			UpdatedAt: now,
+		}
+		if !e.lastProbe.IsZero() {
+			st.LastProbeAt = e.lastProbe.UTC().Format(time.RFC3339)
+		}
+		e.mu.Unlock()
+		if total > 0 {
+			st.Share = math.Round(scores[i]/total*1000) / 1000
+		}
+		out[i] = st
+	}
+	return out
+}
+
+// publish writes every endpoint's snapshot to rdb.KeyUpstreamPool and drops
+// fields for endpoints no longer in the pool (best effort).
+func (p *pool) publish(ctx context.Context, eps []*endpoint) {
+	if p.rdb == nil {
+		return
+	}
+	p.publishing.Lock()
+	defer p.publishing.Unlock()
+
+	keep := make(map[string]bool, len(eps))
+	for _, st := range statuses(eps) {
+		keep[st.Name] = true
+		if data, err := json.Marshal(st); err == nil {
+			_ = p.rdb.HSet(ctx, rdb.KeyUpstreamPool(), st.Name, string(data))
+		}
+	}
+	fields, err := p.rdb.HGetAll(ctx, rdb.KeyUpstreamPool())
+	if err != nil {
+		return
+	}
+	var gone []string
+	for name := range fields {
+		if !keep[name] {
+			gone = append(gone, name)
+		}
+	}
+	if len(gone) > 0 {
+		_ = p.rdb.HDel(ctx, rdb.KeyUpstreamPool(), gone...)
+	}
+}
+
+// Start probes every pool endpoint every UPSTREAM_PROBE_INTERVAL seconds
+// until ctx is cancelled, marking endpoints healthy or unhealthy and
+// publishing the pool state for admin-svc. Each round first refreshes the
+// pool config snapshot that Do and DoFallback read.
+func (c *Client) Start(ctx context.Context) {
+	go func() {
+		for {
+			c.probeAll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.probeInterval(ctx)):
+			}
+		}
+	}()
+}
+
+func (c *Client) probeInterval(ctx context.Context) time.Duration {
+	if v, err := c.cfgSvc.Get(ctx, cfgProbeInterval); err == nil {
+		if n, perr := strconv.Atoi(v); perr == nil && n > 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return defaultProbeInterval
+}
+
+// probeAll probes the current endpoints concurrently, bypassing the breakers.
+// 4xx answers count as healthy: the endpoint is up, only the probe was refused.
+func (c *Client) probeAll(ctx context.Context) {
+	cfg, err := c.refreshConfig(ctx)
+	if err != nil {
+		// Keep serving the previous snapshot.
+		c.log.Warn("upstream probe: read config", zap.Error(err))
+		return
+	}
+	eps := cfg.eps
+	var wg sync.WaitGroup
+	for _, e := range eps {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			pctx, cancel := context.WithTimeout(ctx, probeTimeout)
+			defer cancel()
+			start := time.Now()
+			_, err := c.doRequest(pctx, e.URL, e.APIKey, "", nil, probePath, "")
+			var se *StatusError
+			if errors.As(err, &se) && se.Code < 500 {
+				err = nil
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if !e.probed(time.Since(start), err) {
+				return
+			}
+			if err != nil {
+				c.log.Warn("upstream endpoint unhealthy", zap.String("name", e.Name), zap.String("url", e.URL), zap.Error(err))
+			} else {
+				c.log.Info("upstream endpoint healthy again", zap.String("name", e.Name), zap.String("url", e.URL))
+			}
+		}(e)
+	}
+	wg.Wait()
+	if ctx.Err() == nil {
+		c.pool.publish(ctx, eps)
+	}
+}
@@ -0,0 +1,6 @@
+-- ============================================================
+-- 回滚：proxy_logs（删除父表会级联删除全部分区）
+-- ============================================================
+DROP FUNCTION IF EXISTS drop_proxy_logs_before(DATE);
+DROP FUNCTION IF EXISTS ensure_proxy_logs_partitions(INT);
+DROP TABLE IF EXISTS proxy_logs;
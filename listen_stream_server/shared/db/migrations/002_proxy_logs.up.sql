@@ -0,0 +1,88 @@
+-- ============================================================
+-- Listen Stream — proxy_logs 按天分区的代理访问日志
+-- 写入：proxy-svc（异步批量 COPY）；读取/清理：admin-svc
+-- ============================================================
+
+-- ── 代理日志表（按 created_at 天分区）──────────────────────────
+CREATE TABLE proxy_logs (
+  id           BIGINT      GENERATED ALWAYS AS IDENTITY,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  user_id      TEXT,                    -- JWT sub；内部任务（缓存预热）为 NULL
+  path         TEXT        NOT NULL,    -- 上游路径，如 '/recommend/banner'
+  query_hash   TEXT        NOT NULL DEFAULT '',  -- 与缓存 key 相同的查询串 hash
+  cache_status TEXT        NOT NULL,    -- 'HIT'|'MISS'|'STALE'|'STALE-REVALIDATING'|'ERROR'
+  upstream     TEXT        NOT NULL DEFAULT '',  -- 实际响应的上游 base URL；命中缓存时为空
+  status       INT         NOT NULL,    -- 返回给客户端的 HTTP 状态码
+  latency_ms   INT         NOT NULL,
+  PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- 兜底分区：预建分区缺失时避免写入失败
+CREATE TABLE proxy_logs_default PARTITION OF proxy_logs DEFAULT;
+
+CREATE INDEX proxy_logs_time_idx   ON proxy_logs (created_at DESC);
+CREATE INDEX proxy_logs_status_idx ON proxy_logs (status, created_at DESC);
+CREATE INDEX proxy_logs_path_idx   ON proxy_logs (path, created_at DESC);
+
+-- ensure_proxy_logs_partitions 创建从今天（UTC）起 days_ahead 天的日分区（已存在则跳过）。
+-- 分区边界按 UTC 计算，与会话时区无关。若当天的行已落入兜底分区（例如分区创建前的写入），
+-- 直接 CREATE ... PARTITION OF 会因兜底分区中存在冲突行而失败；因此先建独立表，
+-- 把这些行从兜底分区搬入，再 ATTACH。返回新建的分区数。proxy-svc 启动时及每小时调用一次。
+CREATE FUNCTION ensure_proxy_logs_partitions(days_ahead INT) RETURNS INT
+LANGUAGE plpgsql AS $$
+DECLARE
+  today   DATE := (NOW() AT TIME ZONE 'UTC')::date;
+  d       DATE;
+  lo      TIMESTAMPTZ;
+  hi      TIMESTAMPTZ;
+  part    TEXT;
+  created INT := 0;
+BEGIN
+  FOR i IN 0..days_ahead LOOP
+    d := today + i;
+    lo := d::timestamp AT TIME ZONE 'UTC';
+    hi := (d + 1)::timestamp AT TIME ZONE 'UTC';
+    part := 'proxy_logs_' || to_char(d, 'YYYYMMDD');
+    IF to_regclass(part) IS NULL THEN
+      -- 锁住兜底分区直到事务结束，避免搬运与 ATTACH 之间又有当天的行写入
+      LOCK TABLE proxy_logs_default IN SHARE ROW EXCLUSIVE MODE;
+      EXECUTE format('CREATE TABLE %I (LIKE proxy_logs INCLUDING DEFAULTS)', part);
+      EXECUTE format(
+        'WITH moved AS (DELETE FROM proxy_logs_default WHERE created_at >= %L AND created_at < %L RETURNING *) '
+        'INSERT INTO %I SELECT * FROM moved',
+        lo, hi, part);
+      EXECUTE format(
+        'ALTER TABLE proxy_logs ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)',
+        part, lo, hi);
+      created := created + 1;
+    END IF;
+  END LOOP;
+  RETURN created;
+END;
+$$;
+
+-- drop_proxy_logs_before 删除 cutoff（UTC 日期）之前的全部日分区，并清理兜底分区中的过期行。
+-- 返回删除的分区数。
+CREATE FUNCTION drop_proxy_logs_before(cutoff DATE) RETURNS INT
+LANGUAGE plpgsql AS $$
+DECLARE
+  r       RECORD;
+  dropped INT := 0;
+BEGIN
+  FOR r IN
+    SELECT c.relname
+    FROM pg_inherits i
+    JOIN pg_class c ON c.oid = i.inhrelid
+    WHERE i.inhparent = 'proxy_logs'::regclass
+      AND c.relname ~ '^proxy_logs_[0-9]{8}$'
+      AND to_date(substr(c.relname, 12), 'YYYYMMDD') < cutoff
+  LOOP
+    EXECUTE format('DROP TABLE %I', r.relname);
+    dropped := dropped + 1;
+  END LOOP;
+  DELETE FROM proxy_logs_default WHERE created_at < cutoff::timestamp AT TIME ZONE 'UTC';
+  RETURN dropped;
+END;
+$$;
+
+SELECT ensure_proxy_logs_partitions(7);
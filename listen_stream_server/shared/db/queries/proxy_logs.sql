@@ -0,0 +1,25 @@
+-- ============================================================
+-- proxy_logs 查询（分区表，proxy-svc 批量 COPY 写入）
+-- 使用服务：admin-svc（读查询 + 保留期清理）
+-- ============================================================
+
+-- name: ListProxyLogs :many
+SELECT * FROM proxy_logs
+WHERE (@status::int = 0 OR status = @status)
+  AND (@path::text = '' OR starts_with(path, @path))
+  AND (sqlc.narg('since')::timestamptz IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::timestamptz IS NULL OR created_at < sqlc.narg('until'))
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountProxyLogs :one
+SELECT COUNT(*) FROM proxy_logs
+WHERE (@status::int = 0 OR status = @status)
+  AND (@path::text = '' OR starts_with(path, @path))
+  AND (sqlc.narg('since')::timestamptz IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::timestamptz IS NULL OR created_at < sqlc.narg('until'));
+
+-- name: DropProxyLogsBefore :one
+-- 保留期清理：删除 cutoff 之前的日分区，返回删除的分区数
+SELECT drop_proxy_logs_before(@cutoff::date)::int AS dropped;
+
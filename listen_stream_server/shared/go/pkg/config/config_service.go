@@ -1,6 +1,7 @@
 // Package config provides ConfigService: a thread-safe, encrypted configuration
 // reader backed by PostgreSQL's system_configs table with a 30-second in-memory
-// cache to avoid per-request DB round-trips.
+// cache to avoid per-request DB round-trips. Keys that are not set are cached
+// as missing too, so optional settings left at their defaults cost nothing.
 //
 // Startup contract:
 //  1. Call New(pool, encKey) to create the service.
@@ -58,6 +59,7 @@ var ErrConfigNotFound = errors.New("config: key not found")
 
 type cacheEntry struct {
 	value     string
+	missing   bool // key is not in system_configs; Get returns ErrConfigNotFound
 	expiresAt time.Time
 }
 
@@ -84,11 +86,22 @@ func (s *configService) Get(ctx context.Context, key string) (string, error) {
 	s.mu.RLock()
 	if entry, ok := s.cache[key]; ok && time.Now().Before(entry.expiresAt) {
 		s.mu.RUnlock()
+		cacheHits.Inc()
+		if entry.missing {
+			return "", fmt.Errorf("%w: %q", ErrConfigNotFound, key)
+		}
 		return entry.value, nil
 	}
 	s.mu.RUnlock()
+	cacheMisses.Inc()
 	// Slow path: query DB, then upgrade to write lock to update cache
 	val, err := s.fetchOne(ctx, key)
+	if errors.Is(err, ErrConfigNotFound) {
+		s.mu.Lock()
+		s.cache[key] = cacheEntry{missing: true, expiresAt: time.Now().Add(cacheTTL)}
+		s.mu.Unlock()
+		return "", err
+	}
 	if err != nil {
 		return "", err
 	}
@@ -101,16 +114,24 @@ func (s *configService) Get(ctx context.Context, key string) (string, error) {
 // GetMany returns multiple values; uncached keys are fetched in a single query.
 func (s *configService) GetMany(ctx context.Context, keys []string) (map[string]string, error) {
 	result := make(map[string]string, len(keys))
-	var missing []string
+	var (
+		missing []string
+		hits    int
+	)
 	s.mu.RLock()
 	for _, k := range keys {
 		if entry, ok := s.cache[k]; ok && time.Now().Before(entry.expiresAt) {
-			result[k] = entry.value
+			if !entry.missing {
+				result[k] = entry.value
+			}
+			hits++
 		} else {
 			missing = append(missing, k)
 		}
 	}
 	s.mu.RUnlock()
+	cacheHits.Add(float64(hits))
+	cacheMisses.Add(float64(len(missing)))
 	if len(missing) == 0 {
 		return result, nil
 	}
@@ -123,6 +144,7 @@ func (s *configService) GetMany(ctx context.Context, keys []string) (map[string]
 	defer rows.Close()
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	exp := time.Now().Add(cacheTTL)
 	for rows.Next() {
 		var k, encVal string
 		if err := rows.Scan(&k, &encVal); err != nil {
@@ -132,10 +154,20 @@ func (s *configService) GetMany(ctx context.Context, keys []string) (map[string]
 		if err != nil {
 			return nil, fmt.Errorf("config.GetMany decrypt %q: %w", k, err)
 		}
-		s.cache[k] = cacheEntry{value: plain, expiresAt: time.Now().Add(cacheTTL)}
+		s.cache[k] = cacheEntry{value: plain, expiresAt: exp}
 		result[k] = plain
 	}
-	return result, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("config.GetMany rows: %w", err)
+	}
+	// Remember the keys the query did not return, so the next read of an
+	// unset key is served from cache instead of going back to the DB.
+	for _, k := range missing {
+		if _, ok := result[k]; !ok {
+			s.cache[k] = cacheEntry{missing: true, expiresAt: exp}
+		}
+	}
+	return result, nil
 }
 
 // Set encrypts value and writes to DB, then evicts the cache entry.
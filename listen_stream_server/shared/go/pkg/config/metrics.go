@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"listen-stream/shared/pkg/metrics"
+)
+
+// cacheLookups counts per-key cache reads by Get and GetMany. A miss costs a
+// DB round-trip; a cached "not set" answer counts as a hit.
+var cacheLookups = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "config",
+	Name:      "cache_lookups_total",
+	Help:      "ConfigService cache lookups by result (hit, miss).",
+}, []string{"result"})
+
+var (
+	cacheHits   = cacheLookups.WithLabelValues("hit")
+	cacheMisses = cacheLookups.WithLabelValues("miss")
+)
@@ -0,0 +1,81 @@
+// Package metrics holds the Prometheus registry shared by every service, the
+// Gin middleware that records per-route request counts and latencies, and the
+// server that exposes them on a separate listener.
+//
+// Services register their own domain collectors against Registry, usually
+// through promauto.With(metrics.Registry), next to the code they measure.
+//
+// Startup contract:
+//  1. r.Use(metrics.Middleware()) before any other middleware, so recovered
+//     panics are counted as 500s.
+//  2. Run NewServer(addr).ListenAndServe alongside the API server; it serves
+//     GET /metrics only and should not be exposed publicly.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes every metric name defined in this repo.
+const Namespace = "listen"
+
+// Registry collects the metrics of the running service, including Go runtime
+// and process metrics.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	httpRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route pattern. WebSocket routes measure connection lifetime.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route"})
+)
+
+// unmatchedRoute labels requests that matched no route, so arbitrary paths
+// cannot blow up label cardinality.
+const unmatchedRoute = "unmatched"
+
+// Middleware records every request under its route pattern (c.FullPath(),
+// e.g. "/api/song/url"), never the raw path.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// NewServer returns an HTTP server exposing Registry at GET /metrics on addr.
+func NewServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry}))
+	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+}
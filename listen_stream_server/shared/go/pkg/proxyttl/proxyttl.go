@@ -0,0 +1,133 @@
+// Package proxyttl defines proxy-svc's per-endpoint cache TTL table.
+//
+// Built-in defaults live in Defaults; operators override individual entries
+// through ConfigService (ConfigKey), edited from admin-svc. Keys are the exact
+// upstream paths proxy-svc calls, so a handler's path is also its TTL key.
+package proxyttl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ConfigKey is the ConfigService key holding overrides as a JSON object
+// {"<upstream path>": "<duration>"}, e.g. {"/search/": "10m", "/mv/detail": "0s"}.
+// Durations are Go duration strings or integer seconds; 0 disables caching.
+const ConfigKey = "PROXY_TTLS"
+
+// MaxTTL bounds overrides; the stale copy lives twice as long.
+const MaxTTL = 30 * 24 * time.Hour
+
+// Defaults maps every upstream path proxy-svc serves through its cache
+// pipeline to a Redis cache TTL.
+// TTL == 0 means the response is NOT cached (always forwarded to upstream).
+var Defaults = map[string]time.Duration{
+	"/recommend/banner":     30 * time.Minute,
+	"/recommend/daily":      1 * time.Hour,
+	"/recommend/playlist":   1 * time.Hour,
+	"/recommend/new/songs":  30 * time.Minute,
+	"/recommend/new/albums": 30 * time.Minute,
+	"/playlist/category":    6 * time.Hour,
+	"/playlist/information": 1 * time.Hour,
+	"/playlist/detail":      6 * time.Hour,
+	"/artist/category":      6 * time.Hour,
+	"/artist/list":          2 * time.Hour,
+	"/artist/detail":        12 * time.Hour,
+	"/artist/albums":        12 * time.Hour,
+	"/artist/mvs":           12 * time.Hour,
+	"/artist/songs":         12 * time.Hour,
+	"/rankings/list":        1 * time.Hour,
+	"/rankings/detail":      1 * time.Hour,
+	"/radio/category":       6 * time.Hour,
+	"/radio/songlist":       0, // not cached
+	"/mv/category":          6 * time.Hour,
+	"/mv/list":              1 * time.Hour,
+	"/mv/detail":            0, // not cached
+	"/album/detail":         12 * time.Hour,
+	"/album/songs":          12 * time.Hour,
+	"/search/hotkey":        15 * time.Minute,
+	"/search/":              5 * time.Minute,
+	"/song/detail":          12 * time.Hour,
+	"/lyric/":               7 * 24 * time.Hour,
+}
+
+// Paths returns the keys of Defaults in sorted order.
+func Paths() []string {
+	out := make([]string, 0, len(Defaults))
+	for p := range Defaults {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Parse decodes and validates a ConfigKey value. Every key must be a path in
+// Defaults and every TTL within [0, MaxTTL]. An empty value means no overrides.
+func Parse(raw string) (map[string]time.Duration, error) {
+	out := make(map[string]time.Duration)
+	if raw == "" {
+		return out, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		return nil, fmt.Errorf("proxyttl: %w", err)
+	}
+	for path, v := range m {
+		if _, ok := Defaults[path]; !ok {
+			return nil, fmt.Errorf("proxyttl: unknown path %q", path)
+		}
+		ttl, err := parseTTL(v)
+		if err != nil {
+			return nil, fmt.Errorf("proxyttl: %s: %w", path, err)
+		}
+		out[path] = ttl
+	}
+	return out, nil
+}
+
+// Encode serialises overrides in the ConfigKey format (durations as strings).
+func Encode(overrides map[string]time.Duration) string {
+	m := make(map[string]string, len(overrides))
+	for p, ttl := range overrides {
+		m[p] = ttl.String()
+	}
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// Effective returns Defaults with overrides applied.
+func Effective(overrides map[string]time.Duration) map[string]time.Duration {
+	out := make(map[string]time.Duration, len(Defaults))
+	for p, ttl := range Defaults {
+		out[p] = ttl
+	}
+	for p, ttl := range overrides {
+		out[p] = ttl
+	}
+	return out
+}
+
+func parseTTL(v json.RawMessage) (time.Duration, error) {
+	var ttl time.Duration
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		if n, aerr := strconv.Atoi(s); aerr == nil {
+			ttl = time.Duration(n) * time.Second
+		} else if ttl, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	} else {
+		var n int64
+		if err := json.Unmarshal(v, &n); err != nil {
+			return 0, fmt.Errorf("ttl must be a duration string or integer seconds")
+		}
+		ttl = time.Duration(n) * time.Second
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return 0, fmt.Errorf("ttl %s out of range [0, %s]", ttl, MaxTTL)
+	}
+	return ttl, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	goredis "github.com/redis/go-redis/v9"
 )
 
@@ -24,7 +25,13 @@ func New(redisURL string) *Client {
 	if err != nil {
 		panic(fmt.Sprintf("rdb: invalid REDIS_URL %q: %v", redisURL, err))
 	}
-	return &Client{rdb: goredis.NewClient(opts)}
+	cli := goredis.NewClient(opts)
+	// Commands become spans under the caller's trace (see package tracing).
+	// Arguments are not recorded: keys and values carry phone numbers and tokens.
+	if err := redisotel.InstrumentTracing(cli, redisotel.WithDBStatement(false)); err != nil {
+		panic(fmt.Sprintf("rdb: instrument tracing: %v", err))
+	}
+	return &Client{rdb: cli}
 }
 
 // Ping checks connectivity. Call once at startup.
@@ -50,6 +57,18 @@ func (c *Client) Set(ctx context.Context, key, value string, ttl time.Duration)
 	return c.rdb.Set(ctx, key, value, ttl).Err()
 }
 
+// GetTTL returns the string value of key and its remaining TTL in one round
+// trip. Returns a goredis.Nil error when the key does not exist.
+func (c *Client) GetTTL(ctx context.Context, key string) (string, time.Duration, error) {
+	pipe := c.rdb.Pipeline()
+	get := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", 0, err
+	}
+	return get.Val(), ttl.Val(), nil
+}
+
 // SetNX sets value only if key does NOT exist (atomic).
 // Returns true if the key was set, false if it already existed.
 // Used by D-C: writing RT hash on login (SETNX rt:{device_id}).
@@ -66,6 +85,43 @@ func (c *Client) GetDel(ctx context.Context, key string) (string, error) {
 	return c.rdb.GetDel(ctx, key).Result()
 }
 
+// delIfEqualScript deletes KEYS[1] only when its value equals ARGV[1].
+var delIfEqualScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// DelIfEqual atomically deletes key only if it still holds value.
+// Returns true if the key was deleted. Used to release SETNX-based locks
+// without removing a lock that has since expired and been taken by another owner.
+func (c *Client) DelIfEqual(ctx context.Context, key, value string) (bool, error) {
+	n, err := delIfEqualScript.Run(ctx, c.rdb, []string{key}, value).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// expireIfEqualScript resets the TTL of KEYS[1] to ARGV[2] milliseconds only
+// when its value equals ARGV[1].
+var expireIfEqualScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// ExpireIfEqual atomically resets key's TTL only if it still holds value.
+// Returns false if the key is gone or held by someone else. Used to extend a
+// SETNX-based lock that is still ours.
+func (c *Client) ExpireIfEqual(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	n, err := expireIfEqualScript.Run(ctx, c.rdb, []string{key}, value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
 // Del deletes one or more keys. Silently succeeds if any key is missing.
 func (c *Client) Del(ctx context.Context, keys ...string) error {
 	return c.rdb.Del(ctx, keys...).Err()
@@ -119,6 +175,45 @@ func (c *Client) ScanDel(ctx context.Context, pattern string) (int64, error) {
 	return total, nil
 }
 
+// ScanEach calls fn with each batch of keys matching pattern (SCAN, COUNT 100).
+// A key may be reported more than once if the keyspace changes during the scan.
+func (c *Client) ScanEach(ctx context.Context, pattern string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, nextCursor, err := c.rdb.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return fmt.Errorf("scan %q: %w", pattern, err)
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		cursor = nextCursor
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// TTLs returns the remaining TTL of each key in one pipeline.
+// As with TTL, a missing key or a key without expiry yields a negative duration.
+func (c *Client) TTLs(ctx context.Context, keys []string) ([]time.Duration, error) {
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*goredis.DurationCmd, len(keys))
+	for i, k := range keys {
+		cmds[i] = pipe.TTL(ctx, k)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != goredis.Nil {
+		return nil, err
+	}
+	out := make([]time.Duration, len(keys))
+	for i, cmd := range cmds {
+		out[i] = cmd.Val()
+	}
+	return out, nil
+}
+
 // ── Sorted Sets ──────────────────────────────────────────────────────────────
 
 // ZAddTrim atomically adds member with score to a sorted set, then trims the
@@ -149,6 +244,73 @@ func (c *Client) ZDel(ctx context.Context, key string) error {
 	return c.rdb.Del(ctx, key).Err()
 }
 
+// ── Hashes ───────────────────────────────────────────────────────────────────
+
+// HSet sets field to value in the hash stored at key.
+func (c *Client) HSet(ctx context.Context, key, field, value string) error {
+	return c.rdb.HSet(ctx, key, field, value).Err()
+}
+
+// HDel removes fields from the hash stored at key.
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
+	return c.rdb.HDel(ctx, key, fields...).Err()
+}
+
+// HIncrByMany adds each delta to its field of the hash at key and refreshes
+// the key's TTL, in one pipeline.
+func (c *Client) HIncrByMany(ctx context.Context, key string, deltas map[string]int64, ttl time.Duration) error {
+	pipe := c.rdb.Pipeline()
+	for field, n := range deltas {
+		pipe.HIncrBy(ctx, key, field, n)
+	}
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// HGetAll returns all fields and values of the hash stored at key.
+// Returns an empty map (not an error) when the key does not exist.
+func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.rdb.HGetAll(ctx, key).Result()
+}
+
+// ── Rate limiting ────────────────────────────────────────────────────────────
+
+// tokenBucketScript refills and takes one token from the bucket hash KEYS[1].
+// ARGV: rate (tokens/s), burst. Uses the Redis clock so all replicas agree.
+// Returns {allowed (0|1), wait_ms until the next token}.
+var tokenBucketScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local s = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(s[1]) or burst
+local ts = tonumber(s[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed, wait = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, wait}`)
+
+// TokenBucket takes one token from the bucket at key, refilling at rate
+// tokens per second up to burst. When no token is available it returns
+// allowed == false and how long until one will be.
+// The bucket expires once it would be full again, so idle keys cost nothing.
+func (c *Client) TokenBucket(ctx context.Context, key string, rate float64, burst int) (allowed bool, retryAfter time.Duration, err error) {
+	res, err := tokenBucketScript.Run(ctx, c.rdb, []string{key}, rate, burst).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
+
 // ── Pub/Sub ───────────────────────────────────────────────────────────────────
 
 // Publish sends a message to a channel. Fire-and-forget; errors are logged
@@ -3,7 +3,13 @@
 // key format changes are tracked at compile time.
 package rdb
 
-import "fmt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // ── Auth / Devices ──────────────────────────────────────────
 
@@ -40,11 +46,136 @@ func KeyDevSMSLog() string {
 
 // KeyProxyCache is the Redis key for a cached third-party API response.
 // path: upstream path (e.g. "/recommend/banner")
-// qHash: SHA-256 hex of the sorted query string (first 16 chars for brevity)
+// qHash: ProxyQueryHash(path, rawQuery)
+// Each entry also has a "<key>:stale" backup; fill locks live outside this
+// namespace (KeyProxyFillLock) so scans and purges only see cached responses.
 func KeyProxyCache(path, qHash string) string {
 	return fmt.Sprintf("proxy:%s:%s", path, qHash)
 }
 
+// KeyProxyCachePattern is the SCAN pattern for every proxy cache key whose
+// upstream path starts with pathPrefix ("" matches all paths).
+func KeyProxyCachePattern(pathPrefix string) string {
+	return "proxy:" + escapeGlob(pathPrefix) + "*"
+}
+
+// ProxyQueryHash identifies (path, rawQuery) in proxy cache keys and proxy_logs:
+// the first 8 bytes of SHA-256(path + "?" + sorted query), hex-encoded.
+// Query parameters are sorted so ?a=1&b=2 and ?b=2&a=1 hash identically.
+// admin-svc uses it to purge a single cached query.
+func ProxyQueryHash(path, rawQuery string) string {
+	parts := strings.Split(rawQuery, "&")
+	sort.Strings(parts)
+	h := sha256.Sum256([]byte(path + "?" + strings.Join(parts, "&")))
+	return hex.EncodeToString(h[:8])
+}
+
+// escapeGlob escapes SCAN/KEYS glob metacharacters in s.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// KeyProxyStats is the hourly Redis hash of proxy cache outcomes written by
+// proxy-svc. Field: "<path>|<X-Cache status>"; value: request count.
+// hour: "2006010215" in UTC. TTL == 48 h; admin-svc sums it for hit ratios.
+func KeyProxyStats(hour string) string {
+	return fmt.Sprintf("proxystats:%s", hour)
+}
+
+// KeyProxyTierStats is the hourly Redis hash counting which cache tier served
+// each proxied request. Field: "memory" | "redis" | "upstream"; value: count.
+// hour: "2006010215" in UTC. TTL == 48 h.
+func KeyProxyTierStats(hour string) string {
+	return fmt.Sprintf("proxytier:%s", hour)
+}
+
+// KeyProxyInvalidateChannel is the Pub/Sub channel on which proxy cache
+// invalidations are broadcast so every proxy-svc replica evicts its
+// in-process tier together. Payload: JSON {"origin","key","prefix"} where
+// key is a KeyProxyCache key and prefix an upstream path prefix.
+// Publishers: proxy-svc (on every cache write), admin-svc (on purge).
+func KeyProxyInvalidateChannel() string {
+	return "proxycache:invalidate"
+}
+
+// KeyProxyFillLock is the cross-replica lock held while one proxy-svc instance
+// fetches cacheKey from upstream; the others wait for the cache fill instead.
+// cacheKey: a key built by KeyProxyCache; "proxy:<path>:<hash>" locks as
+// "proxylock:<path>:<hash>", so a purge of proxy:* never drops a held lock.
+// TTL == 25s (fillLockTTL: primary + fallback upstream timeouts); released as
+// soon as the fill finishes, so the TTL only matters for a crashed holder.
+func KeyProxyFillLock(cacheKey string) string {
+	return "proxylock:" + strings.TrimPrefix(cacheKey, "proxy:")
+}
+
+// ── Playback URLs ────────────────────────────────────────────
+
+// KeyPlaybackURL caches the stream URL one playback source resolved for a
+// song at a quality tier. An empty value records that the source had none.
+// For search-based sources, source carries a hash of the request hints.
+// TTL == the signed URL's own expiry minus a margin; short for empty values.
+func KeyPlaybackURL(source, songMID, quality string) string {
+	return fmt.Sprintf("playurl:%s:%s:%s", source, songMID, quality)
+}
+
+// ── Cache Warmer ─────────────────────────────────────────────
+
+// KeyCacheWarmLock is held by the proxy-svc replica running a cache-warm pass,
+// so only one replica refreshes the warm list per tick.
+// TTL == a little under the warm interval; released when the pass finishes.
+func KeyCacheWarmLock() string {
+	return "warm:lock"
+}
+
+// KeyCacheWarmStatus is a Redis hash of the last warm result per entry.
+// Field: "path?query"; value: JSON {"path","query","ok","refreshed","error",
+// "duration_ms","at","instance"}. No TTL; admin-svc reads it for cache stats.
+func KeyCacheWarmStatus() string {
+	return "warm:status"
+}
+
+// ── Upstream Health ─────────────────────────────────────────
+
+// KeyUpstreamBreakers is a Redis hash of circuit-breaker snapshots written by
+// proxy-svc on every state transition. Field: UpstreamBreakerField(instance,
+// base URL), since every replica has its own breakers; value: JSON
+// {"base_url","state","failures","opened_at","updated_at","instance"}.
+// No TTL; admin-svc reads it for the upstream health panel.
+func KeyUpstreamBreakers() string {
+	return "upstream:breakers"
+}
+
+// UpstreamBreakerField is the KeyUpstreamBreakers field for one replica's
+// breaker on baseURL: "<instance>|<baseURL>".
+func UpstreamBreakerField(instance, baseURL string) string {
+	return instance + "|" + baseURL
+}
+
+// KeyUpstreamPool is a Redis hash of upstream endpoint snapshots written by
+// proxy-svc after every health probe. Field: endpoint name; value: JSON
+// upstreams.Status. No TTL; stale entries are overwritten or pruned when an
+// endpoint leaves the pool.
+func KeyUpstreamPool() string {
+	return "upstream:pool"
+}
+
+// ── Rate Limiting ────────────────────────────────────────────
+
+// KeyRateLimit is the token-bucket hash {tokens, ts} for one subject in one
+// proxy-svc route group (e.g. group "song", subject = user ID).
+// TTL == time to refill the bucket; written only by rdb.Client.TokenBucket.
+func KeyRateLimit(group, subject string) string {
+	return fmt.Sprintf("ratelimit:%s:%s", group, subject)
+}
+
 // ── WebSocket Pub/Sub ────────────────────────────────────────
 
 // KeyWSChannel is the Redis Pub/Sub channel for pushing events to a user.
@@ -63,8 +194,16 @@ func KeyAdminFail(username string) string {
 
 // ── Cookie Health ────────────────────────────────────────────
 
-// KeyCookieAlert is set when the scheduled Cookie refresh fails.
+// KeyCookieAlert is set when the scheduled Cookie refresh fails, or by
+// proxy-svc as soon as upstream rejects a cookie.
 // TTL == 24 h; admin dashboard reads this to show an alert banner.
 func KeyCookieAlert() string {
 	return "cookie:alert"
 }
+
+// KeyCookieBad records that upstream rejected the cookie with the given
+// fingerprint (first 8 bytes of its SHA-256, hex). Value: RFC 3339 time.
+// TTL == 24 h.
+func KeyCookieBad(fingerprint string) string {
+	return fmt.Sprintf("cookie:bad:%s", fingerprint)
+}
@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Transport wraps base so every request gets a client span and carries the
+// current trace in its traceparent header. Use it for calls to our own
+// services; base nil means http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base, otelhttp.WithSpanNameFormatter(clientSpanName))
+}
+
+// ExternalTransport is Transport for third-party APIs: requests are traced
+// but no trace headers are sent, so internal trace IDs do not leak.
+func ExternalTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithSpanNameFormatter(clientSpanName),
+		otelhttp.WithPropagators(propagation.NewCompositeTextMapPropagator()),
+	)
+}
+
+// clientSpanName names client spans "GET /search/" rather than just "GET".
+func clientSpanName(_ string, r *http.Request) string {
+	return r.Method + " " + r.URL.Path
+}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const pgxTracerName = "listen-stream/shared/pkg/tracing/pgx"
+
+// maxStatementLen truncates db.query.text; query arguments are never recorded.
+const maxStatementLen = 1024
+
+// QueryTracer is a pgx.QueryTracer that records each Query, QueryRow and
+// Exec as a client span named after its SQL verb ("SELECT", "INSERT", …).
+// NewPool installs it; pools built by hand set cfg.ConnConfig.Tracer.
+type QueryTracer struct{}
+
+var _ pgx.QueryTracer = QueryTracer{}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	stmt := strings.TrimSpace(data.SQL)
+	op := "QUERY"
+	if f := strings.Fields(stmt); len(f) > 0 {
+		op = strings.ToUpper(f[0])
+	}
+	if len(stmt) > maxStatementLen {
+		stmt = stmt[:maxStatementLen]
+	}
+	ctx, _ = Tracer(pgxTracerName).Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(op),
+			semconv.DBQueryText(stmt),
+		),
+	)
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil && data.Err != pgx.ErrNoRows {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	span.End()
+}
+
+// NewPool is pgxpool.New with QueryTracer installed.
+func NewPool(ctx context.Context, connString string) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConnConfig.Tracer = QueryTracer{}
+	return pgxpool.NewWithConfig(ctx, cfg)
+}
@@ -0,0 +1,118 @@
+// Package tracing configures OpenTelemetry tracing for every service and
+// provides the instrumentation shared between them: Gin middleware, HTTP
+// client transports and a pgx query tracer. rdb.Client instruments itself.
+//
+// Export is chosen by the standard OTEL_* environment variables:
+//
+//	OTEL_TRACES_EXPORTER         otlp | stdout | none (default none)
+//	OTEL_EXPORTER_OTLP_ENDPOINT  OTLP/HTTP collector, e.g. http://otel-collector:4318
+//	OTEL_TRACES_SAMPLER(_ARG)    sampler, e.g. parentbased_traceidratio / 0.1
+//	OTEL_SERVICE_NAME            overrides the service name passed to Setup
+//
+// Startup contract:
+//  1. Call Setup before creating clients, and defer its shutdown func so
+//     buffered spans are flushed on exit.
+//  2. r.Use(tracing.Middleware(name)) after metrics.Middleware.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// envExporter selects the span exporter.
+const envExporter = "OTEL_TRACES_EXPORTER"
+
+// Option customises Setup.
+type Option func(*options)
+
+type options struct {
+	exporter sdktrace.SpanExporter
+}
+
+// WithExporter makes Setup export to exp synchronously, ignoring
+// OTEL_TRACES_EXPORTER. Tests pass a tracetest.NewInMemoryExporter so spans
+// are visible as soon as they end.
+func WithExporter(exp sdktrace.SpanExporter) Option {
+	return func(o *options) { o.exporter = exp }
+}
+
+// Setup installs the global tracer provider and W3C trace-context
+// propagation for service. The returned func flushes and stops export; it is
+// a no-op when tracing is disabled. Instrumentation created before Setup
+// (e.g. rdb.New) picks up the provider once it is installed.
+func Setup(ctx context.Context, service string, opts ...Option) (shutdown func(context.Context) error, err error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var export sdktrace.TracerProviderOption
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv(envExporter))); {
+	case o.exporter != nil:
+		export = sdktrace.WithSyncer(o.exporter)
+	case name == "" || name == "none":
+		return func(context.Context) error { return nil }, nil
+	case name == "otlp":
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: otlp exporter: %w", err)
+		}
+		export = sdktrace.WithBatcher(exp)
+	case name == "stdout" || name == "console":
+		exp, err := stdouttrace.New()
+		if err != nil {
+			return nil, fmt.Errorf("tracing: stdout exporter: %w", err)
+		}
+		export = sdktrace.WithBatcher(exp)
+	default:
+		return nil, fmt.Errorf("tracing: unknown %s %q (want otlp, stdout or none)", envExporter, name)
+	}
+
+	// Later detectors win, so OTEL_SERVICE_NAME / OTEL_RESOURCE_ATTRIBUTES
+	// override the built-in name.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(export, sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Tracer returns a tracer from the global provider; name is the
+// instrumenting package's import path.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// Middleware starts a server span per request, continuing the caller's trace
+// from its traceparent header. Spans are named after the route pattern;
+// /health is not traced.
+func Middleware(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service,
+		otelgin.WithFilter(func(r *http.Request) bool { return r.URL.Path != "/health" }),
+	)
+}
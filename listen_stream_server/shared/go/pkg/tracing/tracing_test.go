@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var spans = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	shutdown, err := Setup(context.Background(), "tracing-test", WithExporter(spans))
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	_ = shutdown(context.Background())
+	os.Exit(code)
+}
+
+// headerServer records the traceparent header of every request it answers.
+func headerServer(t *testing.T) (*httptest.Server, *[]string) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("traceparent"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+// serve answers one GET through a Middleware-traced engine whose handler
+// calls target with rt.
+func serve(t *testing.T, rt http.RoundTripper, target string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware("tracing-test"))
+	r.GET("/call", func(c *gin.Context) {
+		req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, target, nil)
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		c.Status(http.StatusNoContent)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/call", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d", w.Code)
+	}
+}
+
+// serverAndClient returns the single server span and single client span
+// exported so far.
+func serverAndClient(t *testing.T) (server, client tracetest.SpanStub) {
+	t.Helper()
+	var nServer, nClient int
+	for _, s := range spans.GetSpans() {
+		switch s.SpanKind {
+		case trace.SpanKindServer:
+			server = s
+			nServer++
+		case trace.SpanKindClient:
+			client = s
+			nClient++
+		}
+	}
+	if nServer != 1 || nClient != 1 {
+		t.Fatalf("got %d server and %d client spans, want one of each", nServer, nClient)
+	}
+	return server, client
+}
+
+func TestTransportPropagates(t *testing.T) {
+	spans.Reset()
+	backend, got := headerServer(t)
+	serve(t, Transport(nil), backend.URL+"/internal")
+
+	server, client := serverAndClient(t)
+	if client.Parent.SpanID() != server.SpanContext.SpanID() || client.SpanContext.TraceID() != server.SpanContext.TraceID() {
+		t.Errorf("client span is not a child of the server span")
+	}
+	if client.Name != "GET /internal" {
+		t.Errorf("client span name = %q", client.Name)
+	}
+	if len(*got) != 1 {
+		t.Fatalf("backend saw %d requests", len(*got))
+	}
+	sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(),
+		propagation.HeaderCarrier{"Traceparent": {(*got)[0]}}))
+	if sc.TraceID() != client.SpanContext.TraceID() || sc.SpanID() != client.SpanContext.SpanID() {
+		t.Errorf("traceparent %q does not name the client span", (*got)[0])
+	}
+}
+
+func TestExternalTransportDoesNotLeak(t *testing.T) {
+	spans.Reset()
+	thirdParty, got := headerServer(t)
+	serve(t, ExternalTransport(nil), thirdParty.URL+"/search/")
+
+	server, client := serverAndClient(t)
+	if client.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("client span is not a child of the server span")
+	}
+	if len(*got) != 1 || (*got)[0] != "" {
+		t.Errorf("third-party host received traceparent %q", *got)
+	}
+}
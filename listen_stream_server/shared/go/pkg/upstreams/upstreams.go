@@ -0,0 +1,119 @@
+// Package upstreams defines the pool of upstream music API endpoints that
+// proxy-svc balances across.
+//
+// The pool is stored in ConfigService (ConfigKey) and edited from admin-svc.
+// When it is unset, proxy-svc falls back to the legacy pair API_BASE_URL
+// (weight 1) and API_FALLBACK_URL (backup), both sharing API_KEY.
+package upstreams
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ConfigKey is the ConfigService key holding the pool as a JSON array of
+// Endpoint, e.g. [{"name":"hk","url":"https://a.example","api_key":"…","weight":3}].
+const ConfigKey = "UPSTREAM_ENDPOINTS"
+
+// MaxWeight bounds Endpoint.Weight.
+const MaxWeight = 100
+
+// Endpoint is one upstream base URL with its own API key.
+//
+// Weight sets the endpoint's share of traffic relative to the others; 0
+// makes it a backup that is only used when no weighted endpoint is usable.
+type Endpoint struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	APIKey string `json:"api_key,omitempty"`
+	Weight int    `json:"weight"`
+}
+
+// Parse decodes and validates a ConfigKey value: names must be unique and
+// non-empty, URLs absolute http(s) without a trailing slash, weights within
+// [0, MaxWeight], and at least one endpoint weighted. An empty value means
+// "not configured" and yields a nil slice.
+func Parse(raw string) ([]Endpoint, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var eps []Endpoint
+	if err := json.Unmarshal([]byte(raw), &eps); err != nil {
+		return nil, fmt.Errorf("upstreams: %w", err)
+	}
+	if err := Validate(eps); err != nil {
+		return nil, err
+	}
+	return eps, nil
+}
+
+// Validate checks eps as described for Parse.
+func Validate(eps []Endpoint) error {
+	if len(eps) == 0 {
+		return fmt.Errorf("upstreams: at least one endpoint is required")
+	}
+	seen := make(map[string]bool, len(eps))
+	weighted := false
+	for _, ep := range eps {
+		if ep.Name == "" {
+			return fmt.Errorf("upstreams: endpoint name is required")
+		}
+		if seen[ep.Name] {
+			return fmt.Errorf("upstreams: duplicate endpoint name %q", ep.Name)
+		}
+		seen[ep.Name] = true
+		u, err := url.Parse(ep.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("upstreams: %s: url must be an absolute http(s) URL", ep.Name)
+		}
+		if strings.HasSuffix(ep.URL, "/") {
+			return fmt.Errorf("upstreams: %s: url must not end with /", ep.Name)
+		}
+		if ep.Weight < 0 || ep.Weight > MaxWeight {
+			return fmt.Errorf("upstreams: %s: weight must be within [0, %d]", ep.Name, MaxWeight)
+		}
+		weighted = weighted || ep.Weight > 0
+	}
+	if !weighted {
+		return fmt.Errorf("upstreams: at least one endpoint needs a weight above 0")
+	}
+	return nil
+}
+
+// Encode serialises eps for storage under ConfigKey.
+func Encode(eps []Endpoint) string {
+	b, _ := json.Marshal(eps)
+	return string(b)
+}
+
+// Legacy builds the pool implied by the pre-pool keys API_BASE_URL,
+// API_FALLBACK_URL and API_KEY. It is empty when baseURL is.
+func Legacy(baseURL, fallbackURL, apiKey string) []Endpoint {
+	if baseURL == "" {
+		return nil
+	}
+	eps := []Endpoint{{Name: "primary", URL: baseURL, APIKey: apiKey, Weight: 1}}
+	if fallbackURL != "" {
+		eps = append(eps, Endpoint{Name: "fallback", URL: fallbackURL, APIKey: apiKey, Weight: 0})
+	}
+	return eps
+}
+
+// Status is a point-in-time snapshot of one endpoint as seen by a proxy-svc
+// replica, published to Redis (rdb.KeyUpstreamPool) for admin-svc.
+type Status struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Weight    int    `json:"weight"`
+	Healthy   bool   `json:"healthy"`
+	LatencyMS int64  `json:"latency_ms"` // moving average over probes and requests
+	// Share is the fraction of new requests this endpoint currently receives
+	// first, from its weight, latency and health.
+	Share       float64 `json:"share"`
+	LastProbeAt string  `json:"last_probe_at,omitempty"`
+	LastError   string  `json:"last_error,omitempty"`
+	Instance    string  `json:"instance"`
+	UpdatedAt   string  `json:"updated_at"`
+}
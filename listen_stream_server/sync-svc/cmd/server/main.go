@@ -16,13 +16,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 
 	"listen-stream/shared/pkg/config"
 	"listen-stream/shared/pkg/crypto"
+	"listen-stream/shared/pkg/metrics"
 	"listen-stream/shared/pkg/rdb"
+	"listen-stream/shared/pkg/tracing"
 	"listen-stream/sync-svc/internal/cron"
 	"listen-stream/sync-svc/internal/handler"
 	syncmw "listen-stream/sync-svc/internal/middleware"
@@ -41,9 +42,15 @@ func main() {
 	}
 	defer logger.Sync() //nolint:errcheck
 
+	// Tracing: exporter chosen by OTEL_TRACES_EXPORTER (default none).
+	shutdownTracing, err := tracing.Setup(context.Background(), "sync-svc")
+	if err != nil {
+		logger.Fatal("init tracing", zap.Error(err))
+	}
+
 	// ── 3. PostgreSQL ─────────────────────────────────────────────────────────
 	dbURL := mustEnv("DATABASE_URL")
-	pool, err := pgxpool.New(context.Background(), dbURL)
+	pool, err := tracing.NewPool(context.Background(), dbURL)
 	if err != nil {
 		logger.Fatal("connect postgres", zap.Error(err))
 	}
@@ -81,7 +88,7 @@ func main() {
 
 	// ── 7. HTTP routes ─────────────────────────────────────────────────────────
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(metrics.Middleware(), tracing.Middleware("sync-svc"), gin.Recovery())
 	r.GET("/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
 
 	// WebSocket endpoint (auth via JWT — Bearer or ?token= — using same RequireUser middleware)
@@ -110,6 +117,15 @@ func main() {
 		}
 	}()
 
+	// Prometheus scrape endpoint on its own listener, kept off the public port.
+	metricsSrv := metrics.NewServer(envOr("METRICS_ADDR", ":9003"))
+	go func() {
+		logger.Info("sync-svc metrics listening", zap.String("addr", metricsSrv.Addr))
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server error", zap.Error(err))
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -122,6 +138,8 @@ func main() {
 	if err := srv.Shutdown(shutCtx); err != nil {
 		logger.Error("graceful shutdown failed", zap.Error(err))
 	}
+	_ = metricsSrv.Shutdown(shutCtx)
+	_ = shutdownTracing(shutCtx)
 }
 
 func mustEnv(key string) string {
@@ -38,6 +38,9 @@ func (h *Hub) register(c *Client) {
 	if h.clients[c.userID] == nil {
 		h.clients[c.userID] = make(map[string]*Client)
 	}
+	if _, replaced := h.clients[c.userID][c.deviceID]; !replaced {
+		wsConnections.Inc()
+	}
 	h.clients[c.userID][c.deviceID] = c
 }
 
@@ -52,6 +55,7 @@ func (h *Hub) unregister(c *Client) {
 	if _, ok := devices[c.deviceID]; ok {
 		delete(devices, c.deviceID)
 		close(c.send)
+		wsConnections.Dec()
 	}
 	if len(devices) == 0 {
 		delete(h.clients, c.userID)
@@ -59,7 +63,7 @@ func (h *Hub) unregister(c *Client) {
 }
 
 // PushToUser serialises msg and delivers it to all of userID's connected clients.
-// Clients whose send channel is full are silently skipped.
+// Clients whose send channel is full are skipped and counted as dropped.
 func (h *Hub) PushToUser(userID string, msg Message) {
 	data, err := json.Marshal(msg)
 	if err != nil {
@@ -72,7 +76,9 @@ func (h *Hub) PushToUser(userID string, msg Message) {
 	for _, c := range devices {
 		select {
 		case c.send <- data:
+			wsDelivered.Inc()
 		default:
+			wsDropped.Inc()
 			h.log.Warn("hub: slow consumer, dropping message",
 				zap.String("user", userID), zap.String("device", c.deviceID))
 		}
@@ -0,0 +1,30 @@
+package ws
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"listen-stream/shared/pkg/metrics"
+)
+
+var (
+	// wsConnections tracks the clients registered in this replica's Hub.
+	wsConnections = promauto.With(metrics.Registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "ws",
+		Name:      "connections",
+		Help:      "WebSocket clients currently connected to this replica.",
+	})
+
+	// wsMessages counts per-device deliveries by PushToUser; "dropped" means
+	// the client's send buffer was full (slow consumer).
+	wsMessages = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "ws",
+		Name:      "messages_total",
+		Help:      "WebSocket messages pushed to clients by result (delivered, dropped).",
+	}, []string{"result"})
+
+	wsDelivered = wsMessages.WithLabelValues("delivered")
+	wsDropped   = wsMessages.WithLabelValues("dropped")
+)